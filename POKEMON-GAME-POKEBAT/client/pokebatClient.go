@@ -2,12 +2,31 @@ package main
 
 import (
     "bufio"
+    "errors"
     "fmt"
     "net"
     "os"
     "strings"
+    "time"
 )
 
+// Large enough for the longest prompts: team profiles and move lists
+const bufferSize = 64 * 1024
+
+// The server sends this when it ends the session, after a battle or a logout
+const loggedOutPrefix = "LOGGED OUT:"
+
+const helpText = `Commands:
+  Choose your team:  three pokemon IDs separated by spaces, e.g. 1 3 4
+  Each turn:         move <number>         use a move (or just its number)
+                     switch <pokemon ID>   send in another pokemon
+                     item <name> [ID]      use a bag item, e.g. item potion 2
+                     forfeit               give up the battle
+  After a faint:     the ID of the pokemon to send in
+  help               show this help
+  logout             leave the server and log in again
+  quit               leave the server and exit`
+
 func main() {
     serverAddr, err := net.ResolveUDPAddr("udp", "localhost:8080")
     if err != nil {
@@ -22,44 +41,137 @@ func main() {
     }
     defer conn.Close()
 
-    reader := bufio.NewReader(os.Stdin)
+    lines := readLines(os.Stdin)
+    messages := receiveMessages(conn)
+
+    // The server logs players out when their battle ends, so log in again
+    // for every battle
+    for {
+        username, ok := login(conn, lines, messages)
+        if !ok {
+            return
+        }
+        if !play(conn, username, lines, messages) {
+            return
+        }
+    }
+}
 
-    var username string
+// Ask for a username until the server accepts it, reporting false if the
+// input ended
+func login(conn *net.UDPConn, lines <-chan string, messages <-chan string) (string, bool) {
     for {
         fmt.Print("Enter your username: ")
-        username, _ = reader.ReadString('\n')
+        username, ok := <-lines
+        if !ok {
+            return "", false
+        }
         username = strings.TrimSpace(username)
+        if username == "" {
+            continue
+        }
 
         sendMessage(conn, "LOGIN:"+username)
 
-        response := receiveMessage(conn)
+        response, ok := waitResponse(messages, 5*time.Second)
+        if !ok {
+            fmt.Println("No response from the server, try again.")
+            continue
+        }
         fmt.Println("Server response:", response)
 
         if strings.HasPrefix(response, "SUCCESS:") {
             fmt.Println("Welcome " + username + "!")
-            break
-        } else if strings.HasPrefix(response, "ERROR:") {
-            fmt.Println("Login failed:", response)
+            fmt.Println(helpText)
+            return username, true
+        }
+        fmt.Println("Login failed:", response)
+    }
+}
+
+// Wait for the server's answer to a login, skipping anything left over from
+// an earlier session
+func waitResponse(messages <-chan string, timeout time.Duration) (string, bool) {
+    deadline := time.After(timeout)
+    for {
+        select {
+        case msg := <-messages:
+            if strings.HasPrefix(msg, "SUCCESS:") || strings.HasPrefix(msg, "FAILED:") || strings.HasPrefix(msg, "ERROR:") {
+                return msg, true
+            }
+        case <-deadline:
+            return "", false
+        }
+    }
+}
+
+// Relay the player's input to the server and print its messages until the
+// session ends. It reports false if the player quit or the input ended.
+func play(conn *net.UDPConn, username string, lines <-chan string, messages <-chan string) bool {
+    for {
+        select {
+        case msg := <-messages:
+            fmt.Println(msg)
+            if strings.HasPrefix(msg, loggedOutPrefix) {
+                return true
+            }
+        case text, ok := <-lines:
+            if !ok {
+                sendMessage(conn, "LOGOUT:"+username)
+                return false
+            }
+            text = strings.TrimSpace(text) // Trim the input to remove leading/trailing whitespace
+            switch strings.ToLower(text) {
+            case "":
+            case "help":
+                fmt.Println(helpText)
+            case "logout":
+                sendMessage(conn, "LOGOUT:"+username)
+            case "quit":
+                sendMessage(conn, "LOGOUT:"+username)
+                return false
+            default:
+                sendMessage(conn, text)
+            }
         }
     }
+}
 
-    // Start a goroutine to continuously receive messages from the server
+// Read the player's input line by line
+func readLines(file *os.File) <-chan string {
+    lines := make(chan string)
     go func() {
+        defer close(lines)
+        reader := bufio.NewReader(file)
         for {
-            msg := receiveMessage(conn)
-            if msg != "" {
-                fmt.Println(msg)
+            text, err := reader.ReadString('\n')
+            if text != "" {
+                lines <- text
+            }
+            if err != nil {
+                return
             }
         }
     }()
+    return lines
+}
 
-    // Main loop to send messages to the server
-    for {
-        fmt.Print("Enter message: ")
-        text, _ := reader.ReadString('\n')
-        text = strings.TrimSpace(text) // Trim the input to remove leading/trailing whitespace
-        sendMessage(conn, text)
-    }
+// Continuously receive messages from the server until the connection is
+// closed
+func receiveMessages(conn *net.UDPConn) <-chan string {
+    messages := make(chan string, 64)
+    go func() {
+        for {
+            msg, err := receiveMessage(conn)
+            if errors.Is(err, net.ErrClosed) {
+                return
+            }
+            if msg != "" {
+                messages <- msg
+            }
+        }
+    }()
+    return messages
 }
 
 func sendMessage(conn *net.UDPConn, message string) {
@@ -73,12 +185,15 @@ func sendMessage(conn *net.UDPConn, message string) {
     }
 }
 
-func receiveMessage(conn *net.UDPConn) string {
-    buffer := make([]byte, 1024)
+func receiveMessage(conn *net.UDPConn) (string, error) {
+    buffer := make([]byte, bufferSize)
     n, _, err := conn.ReadFromUDP(buffer)
     if err != nil {
-        fmt.Println("Error reading from UDP:", err)
-        return ""
+        if !errors.Is(err, net.ErrClosed) {
+            fmt.Println("Error reading from UDP:", err)
+            time.Sleep(time.Second)
+        }
+        return "", err
     }
-    return strings.TrimSpace(string(buffer[:n]))
+    return strings.TrimSpace(string(buffer[:n])), nil
 }
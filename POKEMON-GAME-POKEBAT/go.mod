@@ -1,3 +1,7 @@
 module POKEMON-GAME-POKEBAT
 
 go 1.23.1
+
+require pokemon-game v0.0.0
+
+replace pokemon-game => ../
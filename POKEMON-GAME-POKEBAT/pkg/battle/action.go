@@ -0,0 +1,102 @@
+package battle
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// ActionKind is what a player does in a turn.
+type ActionKind int
+
+const (
+	ActionMove ActionKind = iota
+	ActionItem
+	ActionSwitch
+	ActionForfeit
+)
+
+// Action is a player's choice for a turn. Move is the index of the move
+// in the active fighter's move list, Switch the ID of the Pokemon to send
+// in, Item the name of the item to use and Target the ID of the Pokemon to
+// use it on, 0 for the active fighter.
+type Action struct {
+	Kind   ActionKind
+	Move   int
+	Switch int
+	Item   string
+	Target int
+}
+
+// ActionHelp describes the replies ParseAction accepts.
+const ActionHelp = "move <number> | switch <pokemon ID> | item <name> [pokemon ID] | forfeit"
+
+// ParseAction reads a player's reply to the action prompt, e.g. "move 2",
+// "switch 3", "item potion", "item revive 25" or "forfeit". A bare number
+// picks a move.
+func ParseAction(input string) (Action, error) {
+	fields := strings.Fields(strings.ToLower(input))
+	if len(fields) == 0 {
+		return Action{}, fmt.Errorf("empty action, expected %s", ActionHelp)
+	}
+	if n, err := strconv.Atoi(fields[0]); err == nil && len(fields) == 1 {
+		return Action{Kind: ActionMove, Move: n - 1}, nil
+	}
+
+	switch fields[0] {
+	case "move", "m":
+		if len(fields) != 2 {
+			return Action{}, fmt.Errorf("usage: move <number>")
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return Action{}, fmt.Errorf("invalid move number %q", fields[1])
+		}
+		return Action{Kind: ActionMove, Move: n - 1}, nil
+	case "switch", "s":
+		if len(fields) != 2 {
+			return Action{}, fmt.Errorf("usage: switch <pokemon ID>")
+		}
+		id, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return Action{}, fmt.Errorf("invalid pokemon ID %q", fields[1])
+		}
+		return Action{Kind: ActionSwitch, Switch: id}, nil
+	case "item", "i":
+		if len(fields) < 2 {
+			return Action{}, fmt.Errorf("usage: item <name> [pokemon ID]")
+		}
+		action := Action{Kind: ActionItem}
+		if id, err := strconv.Atoi(fields[len(fields)-1]); err == nil && len(fields) > 2 {
+			action.Target = id
+			fields = fields[:len(fields)-1]
+		}
+		action.Item = strings.Join(fields[1:], " ")
+		return action, nil
+	case "forfeit", "f", "run":
+		return Action{Kind: ActionForfeit}, nil
+	}
+	return Action{}, fmt.Errorf("unknown action %q, expected %s", fields[0], ActionHelp)
+}
+
+// GoesFirst reports whether action a of fighter fa is carried out before
+// action b of fighter fb, both chosen at the start of the turn. Forfeits
+// come first, then switches, then items, then moves. Moves act in order of
+// priority, then of the current speed of their users; a speed tie is
+// settled by r.
+func GoesFirst(a Action, fa *Fighter, b Action, fb *Fighter, r *rand.Rand) bool {
+	if a.Kind != b.Kind {
+		return a.Kind > b.Kind
+	}
+	if a.Kind == ActionMove {
+		pa, pb := Priority(fa.Moves[a.Move]), Priority(fb.Moves[b.Move])
+		if pa != pb {
+			return pa > pb
+		}
+	}
+	if fa.Speed() != fb.Speed() {
+		return fa.Speed() > fb.Speed()
+	}
+	return r.Intn(2) == 0
+}
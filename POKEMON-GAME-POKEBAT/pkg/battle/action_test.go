@@ -0,0 +1,104 @@
+package battle
+
+import (
+	"math/rand"
+	"testing"
+
+	"pokemon-game/dex"
+)
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Action
+		wantErr bool
+	}{
+		{input: "2", want: Action{Kind: ActionMove, Move: 1}},
+		{input: "move 3", want: Action{Kind: ActionMove, Move: 2}},
+		{input: "M 1", want: Action{Kind: ActionMove}},
+		{input: "switch 25", want: Action{Kind: ActionSwitch, Switch: 25}},
+		{input: "s 4", want: Action{Kind: ActionSwitch, Switch: 4}},
+		{input: "item potion", want: Action{Kind: ActionItem, Item: "potion"}},
+		{input: "item super potion 7", want: Action{Kind: ActionItem, Item: "super potion", Target: 7}},
+		{input: "item x sp. atk", want: Action{Kind: ActionItem, Item: "x sp. atk"}},
+		{input: "forfeit", want: Action{Kind: ActionForfeit}},
+		{input: "run", want: Action{Kind: ActionForfeit}},
+		{input: "", wantErr: true},
+		{input: "move", wantErr: true},
+		{input: "move two", wantErr: true},
+		{input: "switch", wantErr: true},
+		{input: "switch pikachu", wantErr: true},
+		{input: "item", wantErr: true},
+		{input: "dance", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAction(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseAction(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseAction(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGoesFirst(t *testing.T) {
+	moves := []dex.Move{
+		{Name: "Tackle"},
+		{Name: "Quick Attack"},
+		{Name: "Counter"},
+	}
+	fighter := func(speed int) *Fighter {
+		f := testFighter("Pokemon", "Normal")
+		f.Stats.Speed = speed
+		f.Moves = moves
+		return f
+	}
+	tackle, quickAttack, counter := Action{Kind: ActionMove, Move: 0}, Action{Kind: ActionMove, Move: 1}, Action{Kind: ActionMove, Move: 2}
+
+	tests := []struct {
+		name   string
+		a      Action
+		speedA int
+		b      Action
+		speedB int
+		want   bool
+	}{
+		{"faster", tackle, 120, tackle, 80, true},
+		{"slower", tackle, 80, tackle, 120, false},
+		{"higher priority beats speed", quickAttack, 10, tackle, 200, true},
+		{"lower priority loses to slower", counter, 200, tackle, 10, false},
+		{"switch before move", Action{Kind: ActionSwitch, Switch: 2}, 10, quickAttack, 200, true},
+		{"item before move", Action{Kind: ActionItem, Item: "potion"}, 10, tackle, 200, true},
+		{"switch before item", Action{Kind: ActionItem, Item: "potion"}, 200, Action{Kind: ActionSwitch, Switch: 2}, 10, false},
+		{"forfeit first", Action{Kind: ActionForfeit}, 10, Action{Kind: ActionSwitch, Switch: 2}, 200, true},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, tt := range tests {
+		if got := GoesFirst(tt.a, fighter(tt.speedA), tt.b, fighter(tt.speedB), r); got != tt.want {
+			t.Errorf("%s: GoesFirst = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// A speed tie is settled by the random source, about half of the time
+	// each way
+	first := 0
+	for i := 0; i < 1000; i++ {
+		if GoesFirst(tackle, fighter(100), tackle, fighter(100), r) {
+			first++
+		}
+	}
+	if first < 400 || first > 600 {
+		t.Errorf("speed tie went first %d times in 1000", first)
+	}
+
+	// Paralysis halves the speed that decides the order
+	slowed := fighter(150)
+	slowed.Pokemon.Status = StatusParalysis
+	if GoesFirst(tackle, slowed, tackle, fighter(100), r) {
+		t.Error("paralysed fighter at 150 speed went before one at 100")
+	}
+}
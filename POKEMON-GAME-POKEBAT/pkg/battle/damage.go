@@ -0,0 +1,267 @@
+package battle
+
+import (
+	"math/rand"
+	"strings"
+
+	"pokemon-game/dex"
+)
+
+const (
+	// basicPower and basicAccuracy are the power and accuracy of BasicAttack.
+	basicPower    = 50
+	basicAccuracy = 95
+	// critChance is the chance of a critical hit, 1 in critChance.
+	critChance = 24
+	// critMultiplier scales the damage of a critical hit.
+	critMultiplier = 1.5
+)
+
+// BasicAttack is the attack of a Pokemon with no known moves: a physical
+// or special attack of one of its own types.
+func BasicAttack(attackType string, special bool) dex.Move {
+	category := "physical"
+	if special {
+		category = "special"
+	}
+	return dex.Move{
+		Name:     category + " " + attackType + " attack",
+		Type:     attackType,
+		Category: category,
+		Power:    basicPower,
+		Accuracy: basicAccuracy,
+	}
+}
+
+// Hit is the outcome of one attack. Status is the status condition the
+// move inflicted, if any; Failed is set for a status move that hit but
+// could not inflict its status and Thawed for a Fire move thawing out a
+// frozen defender. StatChanges are the stat stages the move changed.
+// Effectiveness is 1 for moves that ignore type matchups other than
+// immunities, such as status and fixed-damage moves.
+type Hit struct {
+	Missed        bool
+	Failed        bool
+	Thawed        bool
+	Critical      bool
+	Effectiveness float64
+	Damage        int
+	Status        string
+	StatChanges   []StatChange
+}
+
+// Attack resolves move used by attacker against defender, taking the
+// damage off the defender's HP. Every random roll comes from r, so a
+// battle replayed with the same seed and choices has the same outcome.
+//
+// Damage follows the formula of generation V onwards:
+//
+//	((2*Level/5 + 2) * Power * A/D / 50 + 2) * Critical * Random * STAB * Type * Burn
+//
+// where A and D are the attacker's Attack and defender's Defense, or their
+// special counterparts for a special move, at their stat stages, Critical
+// is 1.5 on a 1 in 24 critical hit, Random is 85-100% and Burn halves the
+// physical damage of a burned attacker. A critical hit ignores the
+// attacker's lowered and the defender's raised stages. Moves whose power
+// depends on the battle get it from variablePower, and fixed-damage moves
+// such as Seismic Toss skip the formula altogether. Misses and status
+// moves deal no damage; any other hit that is not resisted to 0 deals at
+// least 1.
+//
+// A defender immune to the move's type takes no damage and is spared its
+// effects, whether the move deals damage or only inflicts a status or
+// lowers its stats. A damaging move may then inflict its status or change
+// stat stages of a defender left standing, and a Fire move thaws a frozen
+// defender.
+func Attack(attacker, defender *Fighter, move dex.Move, r *rand.Rand) Hit {
+	hit := Hit{Effectiveness: Effectiveness(move.Type, defender.Pokemon.Type)}
+
+	//An accuracy of 0 means the move never misses
+	if move.Accuracy > 0 && r.Intn(100) >= move.Accuracy {
+		hit.Missed = true
+		return hit
+	}
+	effect, hasEffect := moveEffect(move.Name)
+	if move.Category == "status" {
+		//Only moves aimed at the target are stopped by its immunity
+		if hit.Effectiveness == 0 && (hasEffect || affectsTarget(move)) {
+			return hit
+		}
+		hit.Effectiveness = 1
+		if hasEffect {
+			if Inflict(defender, effect.status, r) {
+				hit.Status = effect.status
+			} else {
+				hit.Failed = true
+			}
+		}
+		hit.StatChanges = changeStages(attacker, defender, move, r)
+		return hit
+	}
+	if hit.Effectiveness == 0 {
+		return hit
+	}
+
+	if damage, ok := fixedDamage(attacker, defender, move, r); ok {
+		hit.Effectiveness = 1
+		hit.Damage = damage
+	} else {
+		hit.Critical = r.Intn(critChance) == 0
+		hit.Damage = formulaDamage(attacker, defender, move, hit, r)
+	}
+	defender.HP -= hit.Damage
+	if defender.HP < 0 {
+		defender.HP = 0
+	}
+
+	if defender.Pokemon.Status == StatusFreeze && typeName(move.Type) == "Fire" {
+		defender.Pokemon.Status = ""
+		hit.Thawed = true
+	}
+	if hasEffect && r.Intn(100) < effect.chance && Inflict(defender, effect.status, r) {
+		hit.Status = effect.status
+	}
+	hit.StatChanges = changeStages(attacker, defender, move, r)
+	return hit
+}
+
+// formulaDamage rolls the damage of a damaging move from the stats of the
+// fighters, as described on Attack.
+func formulaDamage(attacker, defender *Fighter, move dex.Move, hit Hit, r *rand.Rand) int {
+	attack, defense := attacker.Stats.Attack, defender.Stats.Defense
+	attackStage, defenseStage := attacker.Stages.Attack, defender.Stages.Defense
+	if move.Category == "special" {
+		attack, defense = attacker.Stats.SpecialAtk, defender.Stats.SpecialDef
+		attackStage, defenseStage = attacker.Stages.SpecialAtk, defender.Stages.SpecialDef
+	}
+	if hit.Critical && attackStage < 0 {
+		attackStage = 0
+	}
+	if hit.Critical && defenseStage > 0 {
+		defenseStage = 0
+	}
+	attack, defense = applyStage(attack, attackStage), applyStage(defense, defenseStage)
+	if defense < 1 {
+		defense = 1
+	}
+
+	base := (2*attacker.level()/5+2)*variablePower(attacker, defender, move)*attack/defense/50 + 2
+
+	damage := float64(base)
+	if hit.Critical {
+		damage *= critMultiplier
+	}
+	damage = float64(int(damage) * (85 + r.Intn(16)) / 100)
+	damage *= STAB(move.Type, attacker.Pokemon.Type) * hit.Effectiveness
+	if attacker.Pokemon.Status == StatusBurn && move.Category == "physical" {
+		damage /= 2
+	}
+
+	if damage < 1 {
+		return 1
+	}
+	return int(damage)
+}
+
+// fixedDamage returns the damage of a move that deals a set amount no
+// matter the stats, type matchup or critical hits, and whether move is one.
+func fixedDamage(attacker, defender *Fighter, move dex.Move, r *rand.Rand) (int, bool) {
+	var damage int
+	switch strings.ToLower(move.Name) {
+	case "seismic toss", "night shade":
+		damage = attacker.level()
+	case "dragon rage":
+		damage = 40
+	case "sonic boom":
+		damage = 20
+	case "super fang", "nature's madness", "ruination":
+		damage = defender.HP / 2
+	case "psywave":
+		damage = attacker.level() * (50 + r.Intn(101)) / 100
+	default:
+		return 0, false
+	}
+	if damage < 1 {
+		damage = 1
+	}
+	return damage, true
+}
+
+// variablePower returns the power of move. The dex lists no power for
+// moves whose power depends on the battle, so it is worked out here from
+// the fighters' HP and speed. Weight-based moves get the power of a
+// medium-weight target, as the dex has no weights, and any other such move
+// the power of BasicAttack.
+func variablePower(attacker, defender *Fighter, move dex.Move) int {
+	if move.Power > 0 {
+		return move.Power
+	}
+	switch strings.ToLower(move.Name) {
+	case "flail", "reversal":
+		ratio := 48 * attacker.HP / max(attacker.Stats.HP, 1)
+		switch {
+		case ratio < 2:
+			return 200
+		case ratio < 5:
+			return 150
+		case ratio < 10:
+			return 100
+		case ratio < 17:
+			return 80
+		case ratio < 33:
+			return 40
+		}
+		return 20
+	case "eruption", "water spout":
+		return max(150*attacker.HP/max(attacker.Stats.HP, 1), 1)
+	case "gyro ball":
+		return min(25*defender.Speed()/max(attacker.Speed(), 1)+1, 150)
+	case "electro ball":
+		ratio := attacker.Speed() / max(defender.Speed(), 1)
+		switch {
+		case ratio >= 4:
+			return 150
+		case ratio >= 3:
+			return 120
+		case ratio >= 2:
+			return 80
+		case ratio >= 1:
+			return 60
+		}
+		return 40
+	case "low kick", "grass knot", "heavy slam", "heat crash":
+		return 60
+	case "return", "frustration":
+		return 102
+	}
+	return basicPower
+}
+
+// affectsTarget reports whether move changes any of the target's stats.
+func affectsTarget(move dex.Move) bool {
+	for _, effect := range moveStages(move.Name) {
+		if !effect.self {
+			return true
+		}
+	}
+	return false
+}
+
+// changeStages applies the stat changes of move that come off: those of
+// the user always, those of the target unless it has fainted.
+func changeStages(attacker, defender *Fighter, move dex.Move, r *rand.Rand) []StatChange {
+	var changes []StatChange
+	for _, effect := range moveStages(move.Name) {
+		target := defender
+		if effect.self {
+			target = attacker
+		}
+		if target.Fainted() || effect.chance < 100 && r.Intn(100) >= effect.chance {
+			continue
+		}
+		change := target.ChangeStage(effect.stat, effect.delta)
+		change.Self = effect.self
+		changes = append(changes, change)
+	}
+	return changes
+}
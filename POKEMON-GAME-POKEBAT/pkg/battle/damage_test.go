@@ -0,0 +1,375 @@
+package battle
+
+import (
+	"math/rand"
+	"testing"
+
+	"POKEMON-GAME-POKEBAT/pkg/player"
+
+	"pokemon-game/dex"
+)
+
+// testFighter returns a level 50 fighter of the given types with 100 in
+// every stat and 200 HP, so the damage of an 80 power move before the
+// random roll is (2*50/5+2)*80*100/100/50+2 = 37.
+func testFighter(name string, types ...string) *Fighter {
+	return &Fighter{
+		Pokemon: player.CapturedPokemon{ID: 1, Name: name, Type: types, Level: 50},
+		Stats:   dex.Stats{HP: 200, Attack: 100, Defense: 100, SpecialAtk: 100, SpecialDef: 100, Speed: 100},
+		HP:      200,
+	}
+}
+
+func TestAttackDamage(t *testing.T) {
+	tests := []struct {
+		name     string
+		attacker *Fighter
+		defender *Fighter
+		move     dex.Move
+		// min and max are the damage of a hit that isn't critical, at
+		// the random rolls of 85% and 100%
+		min, max      int
+		effectiveness float64
+	}{
+		{
+			name:     "neutral",
+			attacker: testFighter("Attacker", "Water"),
+			defender: testFighter("Defender", "Normal"),
+			move:     dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80},
+			min:      31, max: 37, effectiveness: 1,
+		},
+		{
+			name:     "STAB",
+			attacker: testFighter("Attacker", "Normal"),
+			defender: testFighter("Defender", "Normal"),
+			move:     dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80},
+			min:      46, max: 55, effectiveness: 1,
+		},
+		{
+			name:     "STAB and super effective",
+			attacker: testFighter("Attacker", "Water"),
+			defender: testFighter("Defender", "Fire"),
+			move:     dex.Move{Name: "Surf", Type: "Water", Category: "special", Power: 80},
+			min:      93, max: 111, effectiveness: 2,
+		},
+		{
+			name:     "double super effective",
+			attacker: testFighter("Attacker", "Normal"),
+			defender: testFighter("Defender", "Rock", "Ground"),
+			move:     dex.Move{Name: "Surf", Type: "Water", Category: "special", Power: 80},
+			min:      124, max: 148, effectiveness: 4,
+		},
+		{
+			name:     "resisted",
+			attacker: testFighter("Attacker", "Water"),
+			defender: testFighter("Defender", "Grass"),
+			move:     dex.Move{Name: "Surf", Type: "Water", Category: "special", Power: 80},
+			min:      23, max: 27, effectiveness: 0.5,
+		},
+		{
+			name:     "immune",
+			attacker: testFighter("Attacker", "Normal"),
+			defender: testFighter("Defender", "Ghost"),
+			move:     dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80},
+			min:      0, max: 0, effectiveness: 0,
+		},
+		{
+			name: "special attack",
+			attacker: func() *Fighter {
+				f := testFighter("Attacker", "Normal")
+				f.Stats.SpecialAtk = 200
+				return f
+			}(),
+			defender: testFighter("Defender", "Normal"),
+			move:     dex.Move{Name: "Surf", Type: "Water", Category: "special", Power: 80},
+			min:      61, max: 72, effectiveness: 1,
+		},
+		{
+			name: "burned physical attacker",
+			attacker: func() *Fighter {
+				f := testFighter("Attacker", "Normal")
+				f.Pokemon.Status = StatusBurn
+				return f
+			}(),
+			defender: testFighter("Defender", "Normal"),
+			move:     dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80},
+			min:      23, max: 27, effectiveness: 1,
+		},
+		{
+			name: "burned special attacker",
+			attacker: func() *Fighter {
+				f := testFighter("Attacker", "Water")
+				f.Pokemon.Status = StatusBurn
+				return f
+			}(),
+			defender: testFighter("Defender", "Normal"),
+			move:     dex.Move{Name: "Surf", Type: "Water", Category: "special", Power: 80},
+			min:      46, max: 55, effectiveness: 1,
+		},
+		{
+			name: "raised attack stage",
+			attacker: func() *Fighter {
+				f := testFighter("Attacker", "Water")
+				f.Stages.Attack = 2
+				return f
+			}(),
+			defender: testFighter("Defender", "Normal"),
+			move:     dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80},
+			min:      61, max: 72, effectiveness: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		seen := make(map[int]bool)
+		for seed := int64(1); seed <= 200; seed++ {
+			defender := *tt.defender
+			hit := Attack(tt.attacker, &defender, tt.move, rand.New(rand.NewSource(seed)))
+			if hit.Effectiveness != tt.effectiveness {
+				t.Fatalf("%s: effectiveness = %v, want %v", tt.name, hit.Effectiveness, tt.effectiveness)
+			}
+			if hit.Critical {
+				continue
+			}
+			if hit.Damage < tt.min || hit.Damage > tt.max {
+				t.Errorf("%s (seed %d): damage = %d, want %d-%d", tt.name, seed, hit.Damage, tt.min, tt.max)
+			}
+			if defender.HP != tt.defender.HP-hit.Damage {
+				t.Errorf("%s (seed %d): defender HP = %d, want %d", tt.name, seed, defender.HP, tt.defender.HP-hit.Damage)
+			}
+			seen[hit.Damage] = true
+		}
+		if tt.max > tt.min && len(seen) < 2 {
+			t.Errorf("%s: damage never varied over 200 seeds", tt.name)
+		}
+	}
+}
+
+func TestAttackCritical(t *testing.T) {
+	attacker := testFighter("Attacker", "Water")
+	move := dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80}
+
+	const trials = 24000
+	crits := 0
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < trials; i++ {
+		defender := testFighter("Defender", "Normal")
+		// A critical hit ignores the raised defense
+		defender.Stages.Defense = 6
+		hit := Attack(attacker, defender, move, r)
+		if !hit.Critical {
+			if hit.Damage > 13 {
+				t.Fatalf("damage through +6 defense = %d, want at most 13", hit.Damage)
+			}
+			continue
+		}
+		crits++
+		// 37 * 1.5 = 55 before the random roll
+		if hit.Damage < 46 || hit.Damage > 55 {
+			t.Fatalf("critical damage = %d, want 46-55", hit.Damage)
+		}
+	}
+	// 1 in 24 is 1000 of 24000
+	if crits < 850 || crits > 1150 {
+		t.Errorf("%d critical hits in %d attacks, want about %d", crits, trials, trials/critChance)
+	}
+}
+
+func TestAttackAccuracy(t *testing.T) {
+	tests := []struct {
+		accuracy int
+		min, max int
+	}{
+		{accuracy: 0, min: 0, max: 0},
+		{accuracy: 100, min: 0, max: 0},
+		{accuracy: 50, min: 400, max: 600},
+		{accuracy: 1, min: 980, max: 1000},
+	}
+
+	for _, tt := range tests {
+		move := dex.Move{Name: "Slam", Type: "Normal", Category: "physical", Power: 80, Accuracy: tt.accuracy}
+		r := rand.New(rand.NewSource(1))
+		misses := 0
+		for i := 0; i < 1000; i++ {
+			defender := testFighter("Defender", "Normal")
+			hit := Attack(testFighter("Attacker", "Normal"), defender, move, r)
+			if hit.Missed {
+				misses++
+				if hit.Damage != 0 || defender.HP != defender.Stats.HP {
+					t.Fatalf("accuracy %d: a miss dealt %d damage", tt.accuracy, hit.Damage)
+				}
+			}
+		}
+		if misses < tt.min || misses > tt.max {
+			t.Errorf("accuracy %d: %d misses in 1000, want %d-%d", tt.accuracy, misses, tt.min, tt.max)
+		}
+	}
+}
+
+func TestAttackFixedDamage(t *testing.T) {
+	tests := []struct {
+		move          string
+		moveType      string
+		defender      []string
+		defenderHP    int
+		min, max      int
+		effectiveness float64
+	}{
+		{"Seismic Toss", "Fighting", []string{"Normal"}, 200, 50, 50, 1},
+		{"Seismic Toss", "Fighting", []string{"Rock"}, 200, 50, 50, 1},
+		{"Seismic Toss", "Fighting", []string{"Ghost"}, 200, 0, 0, 0},
+		{"Night Shade", "Ghost", []string{"Psychic"}, 200, 50, 50, 1},
+		{"Night Shade", "Ghost", []string{"Normal"}, 200, 0, 0, 0},
+		{"Dragon Rage", "Dragon", []string{"Dragon"}, 200, 40, 40, 1},
+		{"Sonic Boom", "Normal", []string{"Water"}, 200, 20, 20, 1},
+		{"Super Fang", "Normal", []string{"Normal"}, 101, 50, 50, 1},
+		{"Super Fang", "Normal", []string{"Normal"}, 1, 1, 1, 1},
+		{"Psywave", "Psychic", []string{"Normal"}, 200, 25, 75, 1},
+	}
+
+	for _, tt := range tests {
+		move := dex.Move{Name: tt.move, Type: tt.moveType, Category: "special"}
+		for seed := int64(1); seed <= 50; seed++ {
+			defender := testFighter("Defender", tt.defender...)
+			defender.HP = tt.defenderHP
+			hit := Attack(testFighter("Attacker", tt.moveType), defender, move, rand.New(rand.NewSource(seed)))
+			if hit.Critical {
+				t.Errorf("%s: fixed damage landed a critical hit", tt.move)
+			}
+			if hit.Effectiveness != tt.effectiveness {
+				t.Errorf("%s vs %v: effectiveness = %v, want %v", tt.move, tt.defender, hit.Effectiveness, tt.effectiveness)
+			}
+			if hit.Damage < tt.min || hit.Damage > tt.max {
+				t.Errorf("%s vs %v: damage = %d, want %d-%d", tt.move, tt.defender, hit.Damage, tt.min, tt.max)
+			}
+		}
+	}
+}
+
+func TestVariablePower(t *testing.T) {
+	tests := []struct {
+		move          string
+		hp            int
+		speed, target int
+		want          int
+	}{
+		{move: "Flail", hp: 200, speed: 100, target: 100, want: 20},
+		{move: "Flail", hp: 100, speed: 100, target: 100, want: 40},
+		{move: "Reversal", hp: 30, speed: 100, target: 100, want: 100},
+		{move: "Reversal", hp: 1, speed: 100, target: 100, want: 200},
+		{move: "Eruption", hp: 200, speed: 100, target: 100, want: 150},
+		{move: "Water Spout", hp: 100, speed: 100, target: 100, want: 75},
+		{move: "Gyro Ball", hp: 200, speed: 50, target: 100, want: 51},
+		{move: "Gyro Ball", hp: 200, speed: 1, target: 400, want: 150},
+		{move: "Electro Ball", hp: 200, speed: 400, target: 100, want: 150},
+		{move: "Electro Ball", hp: 200, speed: 100, target: 100, want: 60},
+		{move: "Electro Ball", hp: 200, speed: 50, target: 100, want: 40},
+		{move: "Low Kick", hp: 200, speed: 100, target: 100, want: 60},
+		{move: "Return", hp: 200, speed: 100, target: 100, want: 102},
+		{move: "Mystery Move", hp: 200, speed: 100, target: 100, want: basicPower},
+	}
+
+	for _, tt := range tests {
+		attacker, defender := testFighter("Attacker", "Normal"), testFighter("Defender", "Normal")
+		attacker.HP = tt.hp
+		attacker.Stats.Speed, defender.Stats.Speed = tt.speed, tt.target
+		if got := variablePower(attacker, defender, dex.Move{Name: tt.move}); got != tt.want {
+			t.Errorf("variablePower(%s, HP %d, speed %d vs %d) = %d, want %d", tt.move, tt.hp, tt.speed, tt.target, got, tt.want)
+		}
+	}
+
+	// A listed power always wins
+	if got := variablePower(testFighter("A"), testFighter("B"), dex.Move{Name: "Flail", Power: 90}); got != 90 {
+		t.Errorf("variablePower with power 90 = %d, want 90", got)
+	}
+}
+
+func TestAttackStatusMove(t *testing.T) {
+	tests := []struct {
+		name          string
+		move          dex.Move
+		defender      []string
+		status        string
+		failed        bool
+		effectiveness float64
+		attackerStage int
+		defenderStage int
+	}{
+		{
+			name:     "paralyses",
+			move:     dex.Move{Name: "Thunder Wave", Type: "Electric", Category: "status"},
+			defender: []string{"Water"}, status: StatusParalysis, effectiveness: 1,
+		},
+		{
+			name:     "immune type",
+			move:     dex.Move{Name: "Thunder Wave", Type: "Electric", Category: "status"},
+			defender: []string{"Ground"}, effectiveness: 0,
+		},
+		{
+			name:     "immune to the status",
+			move:     dex.Move{Name: "Thunder Wave", Type: "Electric", Category: "status"},
+			defender: []string{"Electric"}, failed: true, effectiveness: 1,
+		},
+		{
+			name:     "lowers the target's stat",
+			move:     dex.Move{Name: "Growl", Type: "Normal", Category: "status"},
+			defender: []string{"Water"}, effectiveness: 1, defenderStage: -1,
+		},
+		{
+			name:     "stat change blocked by immunity",
+			move:     dex.Move{Name: "Growl", Type: "Normal", Category: "status"},
+			defender: []string{"Ghost"}, effectiveness: 0,
+		},
+		{
+			name:     "raises the user's stat",
+			move:     dex.Move{Name: "Swords Dance", Type: "Normal", Category: "status"},
+			defender: []string{"Ghost"}, effectiveness: 1, attackerStage: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		attacker, defender := testFighter("Attacker", "Normal"), testFighter("Defender", tt.defender...)
+		hit := Attack(attacker, defender, tt.move, rand.New(rand.NewSource(1)))
+		if hit.Damage != 0 || defender.HP != defender.Stats.HP {
+			t.Errorf("%s: status move dealt %d damage", tt.name, hit.Damage)
+		}
+		if hit.Status != tt.status || defender.Pokemon.Status != tt.status {
+			t.Errorf("%s: status = %q (defender %q), want %q", tt.name, hit.Status, defender.Pokemon.Status, tt.status)
+		}
+		if hit.Failed != tt.failed {
+			t.Errorf("%s: failed = %v, want %v", tt.name, hit.Failed, tt.failed)
+		}
+		if hit.Effectiveness != tt.effectiveness {
+			t.Errorf("%s: effectiveness = %v, want %v", tt.name, hit.Effectiveness, tt.effectiveness)
+		}
+		if attacker.Stages.Attack != tt.attackerStage || defender.Stages.Attack != tt.defenderStage {
+			t.Errorf("%s: attack stages = %d/%d, want %d/%d", tt.name,
+				attacker.Stages.Attack, defender.Stages.Attack, tt.attackerStage, tt.defenderStage)
+		}
+	}
+}
+
+func TestAttackThaws(t *testing.T) {
+	defender := testFighter("Defender", "Water")
+	defender.Pokemon.Status = StatusFreeze
+	move := dex.Move{Name: "Flamethrower", Type: "Fire", Category: "special", Power: 90}
+
+	hit := Attack(testFighter("Attacker", "Fire"), defender, move, rand.New(rand.NewSource(1)))
+	if !hit.Thawed || defender.Pokemon.Status == StatusFreeze {
+		t.Errorf("Fire move left the defender frozen (thawed %v, status %q)", hit.Thawed, defender.Pokemon.Status)
+	}
+}
+
+func TestAttackSameSeed(t *testing.T) {
+	move := dex.Move{Name: "Body Slam", Type: "Normal", Category: "physical", Power: 85, Accuracy: 100}
+	attack := func() (Hit, *Fighter) {
+		defender := testFighter("Defender", "Water")
+		return Attack(testFighter("Attacker", "Normal"), defender, move, rand.New(rand.NewSource(42))), defender
+	}
+
+	first, firstDefender := attack()
+	second, secondDefender := attack()
+	if first.Damage != second.Damage || first.Critical != second.Critical || first.Status != second.Status ||
+		firstDefender.HP != secondDefender.HP {
+		t.Errorf("same seed gave %+v and %+v", first, second)
+	}
+}
@@ -0,0 +1,93 @@
+package battle
+
+import (
+	"sort"
+
+	"pokemon-game/dex"
+)
+
+// trainerBonus scales the experience given by a trainer's Pokemon, as
+// every Pokemon met on the battle server belongs to a trainer.
+const trainerBonus = 1.5
+
+// Face records that a and b are on the field against each other, so each
+// gets a share of the experience if the other faints.
+func Face(a, b *Fighter) {
+	if a.foes == nil {
+		a.foes = make(map[*Fighter]bool)
+	}
+	if b.foes == nil {
+		b.foes = make(map[*Fighter]bool)
+	}
+	a.foes[b] = true
+	b.foes[a] = true
+}
+
+// ExpShares returns the fighters sharing the experience of fainted: those
+// that faced it and are still standing, in ID order.
+func ExpShares(fainted *Fighter) []*Fighter {
+	var shares []*Fighter
+	for f := range fainted.foes {
+		if !f.Fainted() {
+			shares = append(shares, f)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool { return shares[i].Pokemon.ID < shares[j].Pokemon.ID })
+	return shares
+}
+
+// ExpYield returns the experience each of shares fighters gets when
+// fainted faints:
+//
+//	1.5 * BaseExp * Level / 7 / shares
+//
+// where BaseExp and Level are those of the fainted Pokemon. Every fighter
+// gets at least 1.
+func ExpYield(fainted *Fighter, shares int) int {
+	if shares < 1 {
+		shares = 1
+	}
+	level := fainted.Pokemon.Level
+	if level < 1 {
+		level = 1
+	}
+	exp := int(trainerBonus*float64(fainted.Pokemon.BaseExp*level)/7) / shares
+	if exp < 1 {
+		exp = 1
+	}
+	return exp
+}
+
+// GainExp adds exp to the fighter's total experience and raises its level
+// as far as its growth rate allows, recalculating its stats. Its HP goes
+// up as much as its maximum HP does. It returns the number of levels
+// gained.
+//
+// Records from before experience was a total hold the species base
+// experience instead, which says nothing of their level; experience that
+// doesn't match the level is reset to the start of it first.
+func GainExp(f *Fighter, exp int) int {
+	rate := f.Pokemon.GrowthRate
+	if f.Pokemon.Level < 1 {
+		f.Pokemon.Level = 1
+	}
+	if dex.LevelForExp(rate, f.Pokemon.CurrentExp) != f.Pokemon.Level {
+		f.Pokemon.CurrentExp = dex.ExpForLevel(rate, f.Pokemon.Level)
+	}
+	f.Pokemon.CurrentExp += exp
+	if maxExp := dex.ExpForLevel(rate, dex.MaxLevel); f.Pokemon.CurrentExp > maxExp {
+		f.Pokemon.CurrentExp = maxExp
+	}
+
+	level := dex.LevelForExp(rate, f.Pokemon.CurrentExp)
+	gained := level - f.Pokemon.Level
+	if gained <= 0 {
+		return 0
+	}
+
+	maxHP := f.Stats.HP
+	f.Pokemon.Level = level
+	f.Stats = f.Pokemon.Stats()
+	f.HP += f.Stats.HP - maxHP
+	return gained
+}
@@ -0,0 +1,105 @@
+package battle
+
+import (
+	"testing"
+
+	"pokemon-game/dex"
+)
+
+func TestExpYield(t *testing.T) {
+	tests := []struct {
+		baseExp int
+		level   int
+		shares  int
+		want    int
+	}{
+		// 1.5 * 64 * 5 / 7 = 68.57
+		{64, 5, 1, 68},
+		{64, 5, 2, 34},
+		{64, 5, 0, 68},
+		{112, 50, 1, 1200},
+		{112, 50, 3, 400},
+		{64, 0, 1, 13},
+		{0, 50, 1, 1},
+		{1, 1, 4, 1},
+	}
+
+	for _, tt := range tests {
+		f := testFighter("Fainted", "Normal")
+		f.Pokemon.BaseExp = tt.baseExp
+		f.Pokemon.Level = tt.level
+		if got := ExpYield(f, tt.shares); got != tt.want {
+			t.Errorf("ExpYield(base %d, level %d, %d shares) = %d, want %d", tt.baseExp, tt.level, tt.shares, got, tt.want)
+		}
+	}
+}
+
+func TestExpShares(t *testing.T) {
+	fainted := testFighter("Fainted", "Normal")
+	fainted.HP = 0
+	first, second, down := testFighter("First", "Normal"), testFighter("Second", "Normal"), testFighter("Down", "Normal")
+	first.Pokemon.ID, second.Pokemon.ID, down.Pokemon.ID = 7, 3, 5
+	down.HP = 0
+	for _, f := range []*Fighter{first, second, down} {
+		Face(f, fainted)
+	}
+	// Facing twice counts once
+	Face(first, fainted)
+
+	shares := ExpShares(fainted)
+	if len(shares) != 2 || shares[0] != second || shares[1] != first {
+		var names []string
+		for _, f := range shares {
+			names = append(names, f.Pokemon.Name)
+		}
+		t.Errorf("ExpShares = %v, want [Second First]", names)
+	}
+	if got := ExpShares(testFighter("Alone", "Normal")); len(got) != 0 {
+		t.Errorf("ExpShares of a Pokemon nobody faced = %d fighters", len(got))
+	}
+}
+
+func TestGainExp(t *testing.T) {
+	tests := []struct {
+		name      string
+		rate      string
+		level     int
+		exp       int
+		gain      int
+		wantLevel int
+		wantExp   int
+		gained    int
+	}{
+		{"no level", dex.GrowthMediumFast, 5, 125, 50, 5, 175, 0},
+		{"one level", dex.GrowthMediumFast, 5, 125, 91, 6, 216, 1},
+		{"several levels", dex.GrowthMediumFast, 5, 125, 875, 10, 1000, 5},
+		{"slow", dex.GrowthSlow, 10, 1250, 413, 11, 1663, 1},
+		{"fast", dex.GrowthFast, 10, 800, 264, 11, 1064, 1},
+		{"capped at the highest level", dex.GrowthFast, 99, 776239, 1000000, 100, 800000, 1},
+		{"legacy base experience", dex.GrowthMediumFast, 5, 64, 91, 6, 216, 1},
+		{"no growth rate", "", 5, 125, 91, 6, 216, 1},
+	}
+
+	for _, tt := range tests {
+		f := NewFighter(testFighter("Pokemon", "Normal").Pokemon, nil)
+		f.Pokemon.HP, f.Pokemon.Attack = 50, 50
+		f.Pokemon.Level = tt.level
+		f.Pokemon.CurrentExp = tt.exp
+		f.Pokemon.GrowthRate = tt.rate
+		f.Stats = f.Pokemon.Stats()
+		f.HP = f.Stats.HP - 5
+		maxHP := f.Stats.HP
+
+		gained := GainExp(f, tt.gain)
+		if gained != tt.gained || f.Pokemon.Level != tt.wantLevel || f.Pokemon.CurrentExp != tt.wantExp {
+			t.Errorf("%s: gained %d, level %d, exp %d, want %d, %d, %d",
+				tt.name, gained, f.Pokemon.Level, f.Pokemon.CurrentExp, tt.gained, tt.wantLevel, tt.wantExp)
+		}
+		if f.Stats != f.Pokemon.Stats() {
+			t.Errorf("%s: stats %+v not recalculated for level %d", tt.name, f.Stats, f.Pokemon.Level)
+		}
+		if f.HP != f.Stats.HP-5 {
+			t.Errorf("%s: HP %d/%d, want the %d HP missing before (max was %d)", tt.name, f.HP, f.Stats.HP, 5, maxHP)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package battle
+
+import (
+	"POKEMON-GAME-POKEBAT/pkg/player"
+
+	"pokemon-game/dex"
+)
+
+// Fighter is a Pokemon taking part in a battle. Pokemon is the player's
+// record, whose stat fields hold species base stats; Stats holds the
+// actual stats at its level and HP what is left of Stats.HP. Moves are the
+// moves it can use, at most four and Stages its stat stages while on the
+// field. The status condition is kept on the record; toxicTurns counts the
+// turns of a bad poison since switching in and foes the fighters it has
+// been on the field against.
+type Fighter struct {
+	Pokemon player.CapturedPokemon
+	Stats   dex.Stats
+	HP      int
+	Moves   []dex.Move
+	Stages  Stages
+
+	toxicTurns int
+	foes       map[*Fighter]bool
+}
+
+// NewFighter readies a Pokemon knowing moves for battle at full HP.
+func NewFighter(p player.CapturedPokemon, moves []dex.Move) *Fighter {
+	stats := p.Stats()
+	return &Fighter{Pokemon: p, Stats: stats, HP: stats.HP, Moves: moves}
+}
+
+// Fainted reports whether the fighter has no HP left.
+func (f *Fighter) Fainted() bool {
+	return f.HP <= 0
+}
+
+// SwitchOut clears what the fighter loses when it leaves the field.
+func (f *Fighter) SwitchOut() {
+	f.toxicTurns = 0
+	f.Stages = Stages{}
+}
+
+// level returns the fighter's level, counting records without one as
+// level 1.
+func (f *Fighter) level() int {
+	if f.Pokemon.Level < 1 {
+		return 1
+	}
+	return f.Pokemon.Level
+}
@@ -0,0 +1,174 @@
+package battle
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Item is an item a player can carry in their bag. Bag items are used on a
+// Pokemon in place of a move: Heal restores HP (-1 for all of it), Revive
+// brings a fainted Pokemon back with that percentage of its HP, Cures
+// lists the statuses it cures and Boost raises a stat of the fighter on
+// the field by Stages. Held items are not used from the bag but held by a
+// Pokemon and work on their own at the end of every turn.
+type Item struct {
+	Name   string
+	Heal   int
+	Revive int
+	Cures  []string
+	Boost  string
+	Stages int
+	Held   bool
+}
+
+// allStatuses is what a full heal cures.
+var allStatuses = []string{StatusBurn, StatusPoison, StatusToxic, StatusParalysis, StatusSleep, StatusFreeze}
+
+// items lists the known items by key, see ItemKey.
+var items = map[string]Item{
+	"potion":        {Name: "Potion", Heal: 20},
+	"super potion":  {Name: "Super Potion", Heal: 60},
+	"hyper potion":  {Name: "Hyper Potion", Heal: 120},
+	"max potion":    {Name: "Max Potion", Heal: -1},
+	"full restore":  {Name: "Full Restore", Heal: -1, Cures: allStatuses},
+	"revive":        {Name: "Revive", Revive: 50},
+	"max revive":    {Name: "Max Revive", Revive: 100},
+	"antidote":      {Name: "Antidote", Cures: []string{StatusPoison, StatusToxic}},
+	"burn heal":     {Name: "Burn Heal", Cures: []string{StatusBurn}},
+	"paralyze heal": {Name: "Paralyze Heal", Cures: []string{StatusParalysis}},
+	"awakening":     {Name: "Awakening", Cures: []string{StatusSleep}},
+	"ice heal":      {Name: "Ice Heal", Cures: []string{StatusFreeze}},
+	"full heal":     {Name: "Full Heal", Cures: allStatuses},
+	"x attack":      {Name: "X Attack", Boost: "attack", Stages: 2},
+	"x defense":     {Name: "X Defense", Boost: "defense", Stages: 2},
+	"x sp. atk":     {Name: "X Sp. Atk", Boost: "special_atk", Stages: 2},
+	"x sp. def":     {Name: "X Sp. Def", Boost: "special_def", Stages: 2},
+	"x speed":       {Name: "X Speed", Boost: "speed", Stages: 2},
+	"leftovers":     {Name: "Leftovers", Held: true},
+	"sitrus berry":  {Name: "Sitrus Berry", Held: true},
+	"lum berry":     {Name: "Lum Berry", Held: true},
+	"oran berry":    {Name: "Oran Berry", Held: true},
+}
+
+// ItemKey returns the key of an item name as written by a player or in a
+// bag, e.g. "Super-Potion" to "super potion".
+func ItemKey(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(strings.ReplaceAll(name, "-", " "))), " ")
+}
+
+// LookupItem returns the item with the given name.
+func LookupItem(name string) (Item, bool) {
+	item, ok := items[ItemKey(name)]
+	return item, ok
+}
+
+// Heal restores up to amount HP of the fighter, or all of it for a
+// negative amount, and returns how much it restored.
+func (f *Fighter) Heal(amount int) int {
+	if amount < 0 || f.HP+amount > f.Stats.HP {
+		amount = f.Stats.HP - f.HP
+	}
+	f.HP += amount
+	return amount
+}
+
+// CheckItem returns why item would have no effect on target, or nil if it
+// can be used. active tells whether target is the fighter on the field.
+func CheckItem(item Item, target *Fighter, active bool) error {
+	switch {
+	case item.Held:
+		return fmt.Errorf("%s can only be held by a Pokemon", item.Name)
+	case item.Revive > 0:
+		if !target.Fainted() {
+			return fmt.Errorf("%s has not fainted", target.Pokemon.Name)
+		}
+		return nil
+	case target.Fainted():
+		return fmt.Errorf("%s has fainted", target.Pokemon.Name)
+	case item.Boost != "":
+		if !active {
+			return fmt.Errorf("%s can only be used on the fighter in battle", item.Name)
+		}
+		return nil
+	}
+
+	if item.Heal != 0 && target.HP < target.Stats.HP {
+		return nil
+	}
+	for _, status := range item.Cures {
+		if target.Pokemon.Status == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("it won't have any effect on %s", target.Pokemon.Name)
+}
+
+// UseItem uses item on target, which CheckItem has to accept, and returns
+// the lines describing what it did.
+func UseItem(item Item, target *Fighter) []string {
+	name := target.Pokemon.Name
+	var messages []string
+
+	if item.Revive > 0 {
+		target.HP = 0
+		target.Heal(target.Stats.HP * item.Revive / 100)
+		target.Pokemon.Status = ""
+		target.Pokemon.SleepTurns = 0
+		return append(messages, fmt.Sprintf("%s was revived! (HP %d/%d)", name, target.HP, target.Stats.HP))
+	}
+	if item.Heal != 0 {
+		if healed := target.Heal(item.Heal); healed > 0 {
+			messages = append(messages, fmt.Sprintf("%s's HP was restored by %d. (HP %d/%d)", name, healed, target.HP, target.Stats.HP))
+		}
+	}
+	for _, status := range item.Cures {
+		if target.Pokemon.Status == status {
+			target.Pokemon.Status = ""
+			target.Pokemon.SleepTurns = 0
+			messages = append(messages, fmt.Sprintf("%s was cured of its %s.", name, status))
+			break
+		}
+	}
+	if item.Boost != "" {
+		messages = append(messages, StageMessage(name, target.ChangeStage(item.Boost, item.Stages)))
+	}
+	return messages
+}
+
+// HeldItemEffect applies the end of turn effect of the item the fighter
+// holds and returns the line describing it, or "". Berries are eaten and
+// gone once they have worked.
+func HeldItemEffect(f *Fighter) string {
+	if f.Fainted() {
+		return ""
+	}
+
+	name := f.Pokemon.Name
+	switch ItemKey(f.Pokemon.HeldItem) {
+	case "leftovers":
+		if healed := f.Heal(max(f.Stats.HP/16, 1)); healed > 0 {
+			return fmt.Sprintf("%s restored a little HP using its Leftovers! (+%d HP)", name, healed)
+		}
+	case "sitrus berry":
+		if f.HP <= f.Stats.HP/2 {
+			f.Pokemon.HeldItem = ""
+			healed := f.Heal(max(f.Stats.HP/4, 1))
+			return fmt.Sprintf("%s ate its Sitrus Berry and restored HP! (+%d HP)", name, healed)
+		}
+	case "oran berry":
+		if f.HP <= f.Stats.HP/2 {
+			f.Pokemon.HeldItem = ""
+			healed := f.Heal(10)
+			return fmt.Sprintf("%s ate its Oran Berry and restored HP! (+%d HP)", name, healed)
+		}
+	case "lum berry":
+		if f.Pokemon.Status != "" {
+			status := f.Pokemon.Status
+			f.Pokemon.HeldItem = ""
+			f.Pokemon.Status = ""
+			f.Pokemon.SleepTurns = 0
+			return fmt.Sprintf("%s ate its Lum Berry and was cured of its %s!", name, status)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,144 @@
+package battle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupItem(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"potion", "Potion", true},
+		{"Super-Potion", "Super Potion", true},
+		{"  FULL   restore ", "Full Restore", true},
+		{"x sp. atk", "X Sp. Atk", true},
+		{"Leftovers", "Leftovers", true},
+		{"master ball", "", false},
+	}
+
+	for _, tt := range tests {
+		item, ok := LookupItem(tt.name)
+		if ok != tt.ok || item.Name != tt.want {
+			t.Errorf("LookupItem(%q) = %q, %v, want %q, %v", tt.name, item.Name, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCheckItem(t *testing.T) {
+	tests := []struct {
+		item    string
+		hp      int
+		status  string
+		active  bool
+		wantErr bool
+	}{
+		{item: "potion", hp: 100, active: true},
+		{item: "potion", hp: 200, active: true, wantErr: true},
+		{item: "potion", hp: 0, active: true, wantErr: true},
+		{item: "revive", hp: 0},
+		{item: "revive", hp: 100, wantErr: true},
+		{item: "antidote", hp: 200, status: StatusToxic},
+		{item: "antidote", hp: 200, status: StatusBurn, wantErr: true},
+		{item: "full restore", hp: 200, status: StatusSleep},
+		{item: "x attack", hp: 200, active: true},
+		{item: "x attack", hp: 200, wantErr: true},
+		{item: "leftovers", hp: 100, active: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		item, _ := LookupItem(tt.item)
+		f := testFighter("Pokemon", "Normal")
+		f.HP = tt.hp
+		f.Pokemon.Status = tt.status
+		if err := CheckItem(item, f, tt.active); (err != nil) != tt.wantErr {
+			t.Errorf("CheckItem(%s, HP %d, %q) = %v, want error %v", tt.item, tt.hp, tt.status, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUseItem(t *testing.T) {
+	tests := []struct {
+		item       string
+		hp         int
+		status     string
+		wantHP     int
+		wantStatus string
+		wantAttack int
+	}{
+		{item: "potion", hp: 100, wantHP: 120},
+		{item: "potion", hp: 190, wantHP: 200},
+		{item: "hyper potion", hp: 50, wantHP: 170},
+		{item: "max potion", hp: 1, wantHP: 200},
+		{item: "full restore", hp: 1, status: StatusParalysis, wantHP: 200},
+		{item: "paralyze heal", hp: 100, status: StatusParalysis, wantHP: 100},
+		{item: "revive", hp: 0, status: StatusBurn, wantHP: 100},
+		{item: "max revive", hp: 0, wantHP: 200},
+		{item: "x attack", hp: 200, wantHP: 200, wantAttack: 2},
+	}
+
+	for _, tt := range tests {
+		item, _ := LookupItem(tt.item)
+		f := testFighter("Pokemon", "Normal")
+		f.HP = tt.hp
+		f.Pokemon.Status = tt.status
+		messages := UseItem(item, f)
+		if f.HP != tt.wantHP || f.Pokemon.Status != tt.wantStatus || f.Stages.Attack != tt.wantAttack {
+			t.Errorf("UseItem(%s): HP %d, status %q, attack stage %d, want %d, %q, %d",
+				tt.item, f.HP, f.Pokemon.Status, f.Stages.Attack, tt.wantHP, tt.wantStatus, tt.wantAttack)
+		}
+		if len(messages) == 0 {
+			t.Errorf("UseItem(%s) described nothing", tt.item)
+		}
+	}
+}
+
+func TestHeldItemEffect(t *testing.T) {
+	tests := []struct {
+		item       string
+		hp         int
+		status     string
+		wantHP     int
+		wantStatus string
+		eaten      bool
+	}{
+		{item: "Leftovers", hp: 100, wantHP: 112},
+		{item: "Leftovers", hp: 200, wantHP: 200},
+		{item: "Sitrus Berry", hp: 100, wantHP: 150, eaten: true},
+		{item: "Sitrus Berry", hp: 101, wantHP: 101},
+		{item: "Oran Berry", hp: 40, wantHP: 50, eaten: true},
+		{item: "Lum Berry", hp: 200, status: StatusFreeze, wantHP: 200, eaten: true},
+		{item: "Lum Berry", hp: 200, wantHP: 200},
+		{item: "", hp: 100, wantHP: 100},
+	}
+
+	for _, tt := range tests {
+		f := testFighter("Pokemon", "Normal")
+		f.HP = tt.hp
+		f.Pokemon.Status = tt.status
+		f.Pokemon.HeldItem = tt.item
+		message := HeldItemEffect(f)
+		if f.HP != tt.wantHP || f.Pokemon.Status != tt.wantStatus {
+			t.Errorf("%s at %d HP: HP %d, status %q, want %d, %q", tt.item, tt.hp, f.HP, f.Pokemon.Status, tt.wantHP, tt.wantStatus)
+		}
+		if eaten := f.Pokemon.HeldItem == ""; eaten != (tt.eaten || tt.item == "") {
+			t.Errorf("%s at %d HP: held item %q left", tt.item, tt.hp, f.Pokemon.HeldItem)
+		}
+		if (message != "") != (f.HP != tt.hp || tt.eaten) {
+			t.Errorf("%s at %d HP: message %q", tt.item, tt.hp, message)
+		}
+		if message != "" && !strings.Contains(message, "Pokemon") {
+			t.Errorf("%s: message %q doesn't name the Pokemon", tt.item, message)
+		}
+	}
+
+	// A fainted Pokemon's berry stays uneaten
+	f := testFighter("Pokemon", "Normal")
+	f.HP = 0
+	f.Pokemon.HeldItem = "Sitrus Berry"
+	if message := HeldItemEffect(f); message != "" || f.HP != 0 {
+		t.Errorf("fainted Pokemon used its berry: %q", message)
+	}
+}
@@ -0,0 +1,45 @@
+package battle
+
+import (
+	"strings"
+
+	"pokemon-game/dex"
+)
+
+// movePriorities lists the priority of moves acting before or after
+// ordinary moves, for move databases scraped without priorities.
+var movePriorities = map[string]int{
+	"helping hand": 5,
+	"protect":      4, "detect": 4, "endure": 4, "magic coat": 4, "snatch": 4,
+	"fake out": 3, "quick guard": 3, "wide guard": 3,
+	"extreme speed": 2, "feint": 2, "follow me": 2, "rage powder": 2, "first impression": 2, "ally switch": 2,
+	"quick attack": 1, "mach punch": 1, "bullet punch": 1, "ice shard": 1, "shadow sneak": 1,
+	"aqua jet": 1, "vacuum wave": 1, "sucker punch": 1, "accelerock": 1, "water shuriken": 1,
+	"jet punch": 1, "baby-doll eyes": 1,
+	"vital throw": -1,
+	"focus punch": -3, "beak blast": -3, "shell trap": -3,
+	"avalanche": -4, "revenge": -4,
+	"counter": -5, "mirror coat": -5,
+	"roar": -6, "whirlwind": -6, "dragon tail": -6, "circle throw": -6,
+	"trick room": -7,
+}
+
+// Priority returns the priority bracket of a move: moves of a higher
+// bracket act first whatever the speed of their users.
+func Priority(move dex.Move) int {
+	if move.Priority != 0 {
+		return move.Priority
+	}
+	return movePriorities[strings.ToLower(move.Name)]
+}
+
+// Speed returns the current speed of the fighter, which decides the order
+// of moves of the same priority: its Speed at its speed stage, halved by
+// paralysis.
+func (f *Fighter) Speed() int {
+	speed := applyStage(f.Stats.Speed, f.Stages.Speed)
+	if f.Pokemon.Status == StatusParalysis {
+		return speed / 2
+	}
+	return speed
+}
@@ -0,0 +1,57 @@
+package battle
+
+import (
+	"testing"
+
+	"pokemon-game/dex"
+)
+
+func TestPriority(t *testing.T) {
+	tests := []struct {
+		move dex.Move
+		want int
+	}{
+		{dex.Move{Name: "Tackle"}, 0},
+		{dex.Move{Name: "Quick Attack"}, 1},
+		{dex.Move{Name: "EXTREME SPEED"}, 2},
+		{dex.Move{Name: "Protect"}, 4},
+		{dex.Move{Name: "Counter"}, -5},
+		{dex.Move{Name: "Trick Room"}, -7},
+		// A priority from the move database wins over the table
+		{dex.Move{Name: "Quick Attack", Priority: 2}, 2},
+		{dex.Move{Name: "Tackle", Priority: -1}, -1},
+	}
+
+	for _, tt := range tests {
+		if got := Priority(tt.move); got != tt.want {
+			t.Errorf("Priority(%+v) = %d, want %d", tt.move, got, tt.want)
+		}
+	}
+}
+
+func TestSpeed(t *testing.T) {
+	tests := []struct {
+		stage  int
+		status string
+		want   int
+	}{
+		{0, "", 100},
+		{1, "", 150},
+		{2, "", 200},
+		{6, "", 400},
+		{-1, "", 66},
+		{-6, "", 25},
+		{0, StatusParalysis, 50},
+		{2, StatusParalysis, 100},
+		{0, StatusBurn, 100},
+	}
+
+	for _, tt := range tests {
+		f := testFighter("Pokemon", "Normal")
+		f.Stages.Speed = tt.stage
+		f.Pokemon.Status = tt.status
+		if got := f.Speed(); got != tt.want {
+			t.Errorf("Speed at stage %d with status %q = %d, want %d", tt.stage, tt.status, got, tt.want)
+		}
+	}
+}
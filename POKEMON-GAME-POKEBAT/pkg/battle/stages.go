@@ -0,0 +1,185 @@
+package battle
+
+import (
+	"strings"
+)
+
+// MaxStage is how far a stat stage can go up or down.
+const MaxStage = 6
+
+// Stages are the stat stages of a fighter on the field, from -6 to +6.
+// Each stage up multiplies the stat by (2+stage)/2, each stage down by
+// 2/(2-stage). They are lost when the fighter switches out.
+type Stages struct {
+	Attack     int
+	Defense    int
+	SpecialAtk int
+	SpecialDef int
+	Speed      int
+}
+
+// stat returns the stage of a stat named as in the JSON files, or nil for
+// an unknown name.
+func (s *Stages) stat(name string) *int {
+	switch name {
+	case "attack":
+		return &s.Attack
+	case "defense":
+		return &s.Defense
+	case "special_atk":
+		return &s.SpecialAtk
+	case "special_def":
+		return &s.SpecialDef
+	case "speed":
+		return &s.Speed
+	}
+	return nil
+}
+
+// statLabels are the names of the stats shown to players.
+var statLabels = map[string]string{
+	"attack":      "Attack",
+	"defense":     "Defense",
+	"special_atk": "Sp. Atk",
+	"special_def": "Sp. Def",
+	"speed":       "Speed",
+}
+
+// applyStage returns stat at the given stage.
+func applyStage(stat, stage int) int {
+	if stage >= 0 {
+		return stat * (2 + stage) / 2
+	}
+	return stat * 2 / (2 - stage)
+}
+
+// StatChange is a change of a stat stage by a move or an item. Wanted is
+// the change asked for and Change the part of it that was applied, less
+// when the stage hit -6 or +6. Self is set when the move changed its
+// user's stats rather than its target's.
+type StatChange struct {
+	Stat   string
+	Wanted int
+	Change int
+	Self   bool
+}
+
+// ChangeStage raises or lowers a stat stage of the fighter by delta,
+// keeping it within -6 to +6.
+func (f *Fighter) ChangeStage(stat string, delta int) StatChange {
+	change := StatChange{Stat: stat, Wanted: delta}
+	stage := f.Stages.stat(stat)
+	if stage == nil {
+		return change
+	}
+
+	next := *stage + delta
+	if next > MaxStage {
+		next = MaxStage
+	}
+	if next < -MaxStage {
+		next = -MaxStage
+	}
+	change.Change = next - *stage
+	*stage = next
+	return change
+}
+
+// StageMessage describes a stat change of the Pokemon called name.
+func StageMessage(name string, c StatChange) string {
+	stat := name + "'s " + statLabels[c.Stat]
+	switch {
+	case c.Change == 0 && c.Wanted > 0:
+		return stat + " won't go any higher!"
+	case c.Change == 0:
+		return stat + " won't go any lower!"
+	case c.Change >= 3:
+		return stat + " rose drastically!"
+	case c.Change == 2:
+		return stat + " rose sharply!"
+	case c.Change == 1:
+		return stat + " rose!"
+	case c.Change == -1:
+		return stat + " fell!"
+	case c.Change == -2:
+		return stat + " harshly fell!"
+	}
+	return stat + " severely fell!"
+}
+
+// stageEffect is a stat change a move makes, with its chance in percent.
+type stageEffect struct {
+	stat   string
+	delta  int
+	self   bool
+	chance int
+}
+
+// moveStageEffects lists the moves changing stat stages: status moves
+// always do when they hit, damaging moves with the given chance.
+var moveStageEffects = map[string][]stageEffect{
+	"swords dance":   {{"attack", 2, true, 100}},
+	"howl":           {{"attack", 1, true, 100}},
+	"meditate":       {{"attack", 1, true, 100}},
+	"sharpen":        {{"attack", 1, true, 100}},
+	"harden":         {{"defense", 1, true, 100}},
+	"withdraw":       {{"defense", 1, true, 100}},
+	"defense curl":   {{"defense", 1, true, 100}},
+	"iron defense":   {{"defense", 2, true, 100}},
+	"barrier":        {{"defense", 2, true, 100}},
+	"acid armor":     {{"defense", 2, true, 100}},
+	"nasty plot":     {{"special_atk", 2, true, 100}},
+	"amnesia":        {{"special_def", 2, true, 100}},
+	"agility":        {{"speed", 2, true, 100}},
+	"rock polish":    {{"speed", 2, true, 100}},
+	"bulk up":        {{"attack", 1, true, 100}, {"defense", 1, true, 100}},
+	"calm mind":      {{"special_atk", 1, true, 100}, {"special_def", 1, true, 100}},
+	"dragon dance":   {{"attack", 1, true, 100}, {"speed", 1, true, 100}},
+	"growth":         {{"attack", 1, true, 100}, {"special_atk", 1, true, 100}},
+	"cosmic power":   {{"defense", 1, true, 100}, {"special_def", 1, true, 100}},
+	"quiver dance":   {{"special_atk", 1, true, 100}, {"special_def", 1, true, 100}, {"speed", 1, true, 100}},
+	"growl":          {{"attack", -1, false, 100}},
+	"charm":          {{"attack", -2, false, 100}},
+	"feather dance":  {{"attack", -2, false, 100}},
+	"tail whip":      {{"defense", -1, false, 100}},
+	"leer":           {{"defense", -1, false, 100}},
+	"screech":        {{"defense", -2, false, 100}},
+	"fake tears":     {{"special_def", -2, false, 100}},
+	"metal sound":    {{"special_def", -2, false, 100}},
+	"string shot":    {{"speed", -2, false, 100}},
+	"scary face":     {{"speed", -2, false, 100}},
+	"cotton spore":   {{"speed", -2, false, 100}},
+	"tickle":         {{"attack", -1, false, 100}, {"defense", -1, false, 100}},
+	"icy wind":       {{"speed", -1, false, 100}},
+	"rock tomb":      {{"speed", -1, false, 100}},
+	"mud shot":       {{"speed", -1, false, 100}},
+	"bulldoze":       {{"speed", -1, false, 100}},
+	"snarl":          {{"special_atk", -1, false, 100}},
+	"mystical fire":  {{"special_atk", -1, false, 100}},
+	"acid spray":     {{"special_def", -2, false, 100}},
+	"crunch":         {{"defense", -1, false, 20}},
+	"iron tail":      {{"defense", -1, false, 30}},
+	"psychic":        {{"special_def", -1, false, 10}},
+	"shadow ball":    {{"special_def", -1, false, 20}},
+	"energy ball":    {{"special_def", -1, false, 10}},
+	"bug buzz":       {{"special_def", -1, false, 10}},
+	"focus blast":    {{"special_def", -1, false, 10}},
+	"earth power":    {{"special_def", -1, false, 10}},
+	"flash cannon":   {{"special_def", -1, false, 10}},
+	"aurora beam":    {{"attack", -1, false, 10}},
+	"bubble beam":    {{"speed", -1, false, 10}},
+	"metal claw":     {{"attack", 1, true, 10}},
+	"power-up punch": {{"attack", 1, true, 100}},
+	"flame charge":   {{"speed", 1, true, 100}},
+	"close combat":   {{"defense", -1, true, 100}, {"special_def", -1, true, 100}},
+	"superpower":     {{"attack", -1, true, 100}, {"defense", -1, true, 100}},
+	"overheat":       {{"special_atk", -2, true, 100}},
+	"draco meteor":   {{"special_atk", -2, true, 100}},
+	"leaf storm":     {{"special_atk", -2, true, 100}},
+	"psycho boost":   {{"special_atk", -2, true, 100}},
+}
+
+// moveStages returns the stat changes move makes, if any.
+func moveStages(name string) []stageEffect {
+	return moveStageEffects[strings.ToLower(name)]
+}
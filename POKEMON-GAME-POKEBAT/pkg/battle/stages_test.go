@@ -0,0 +1,83 @@
+package battle
+
+import "testing"
+
+func TestApplyStage(t *testing.T) {
+	tests := []struct {
+		stage int
+		want  int
+	}{
+		{-6, 25},
+		{-3, 40},
+		{-2, 50},
+		{-1, 66},
+		{0, 100},
+		{1, 150},
+		{2, 200},
+		{3, 250},
+		{6, 400},
+	}
+
+	for _, tt := range tests {
+		if got := applyStage(100, tt.stage); got != tt.want {
+			t.Errorf("applyStage(100, %d) = %d, want %d", tt.stage, got, tt.want)
+		}
+	}
+}
+
+func TestChangeStage(t *testing.T) {
+	tests := []struct {
+		stat   string
+		start  int
+		delta  int
+		want   int
+		change int
+	}{
+		{"attack", 0, 2, 2, 2},
+		{"defense", 0, -1, -1, -1},
+		{"speed", 5, 2, 6, 1},
+		{"special_atk", 6, 1, 6, 0},
+		{"special_def", -5, -3, -6, -1},
+		{"special_def", -6, -1, -6, 0},
+	}
+
+	for _, tt := range tests {
+		f := testFighter("Pokemon", "Normal")
+		*f.Stages.stat(tt.stat) = tt.start
+		got := f.ChangeStage(tt.stat, tt.delta)
+		if *f.Stages.stat(tt.stat) != tt.want {
+			t.Errorf("%s %+d from %d: stage = %d, want %d", tt.stat, tt.delta, tt.start, *f.Stages.stat(tt.stat), tt.want)
+		}
+		if got != (StatChange{Stat: tt.stat, Wanted: tt.delta, Change: tt.change}) {
+			t.Errorf("%s %+d from %d: change = %+v, want %d", tt.stat, tt.delta, tt.start, got, tt.change)
+		}
+	}
+
+	// Stats without stages are left alone
+	f := testFighter("Pokemon", "Normal")
+	if got := f.ChangeStage("hp", 1); got.Change != 0 || f.Stages != (Stages{}) {
+		t.Errorf("ChangeStage(hp) = %+v, stages %+v", got, f.Stages)
+	}
+}
+
+func TestStageMessage(t *testing.T) {
+	tests := []struct {
+		change StatChange
+		want   string
+	}{
+		{StatChange{Stat: "attack", Wanted: 1, Change: 1}, "Pikachu's Attack rose!"},
+		{StatChange{Stat: "defense", Wanted: 2, Change: 2}, "Pikachu's Defense rose sharply!"},
+		{StatChange{Stat: "special_atk", Wanted: 3, Change: 3}, "Pikachu's Sp. Atk rose drastically!"},
+		{StatChange{Stat: "special_def", Wanted: -1, Change: -1}, "Pikachu's Sp. Def fell!"},
+		{StatChange{Stat: "speed", Wanted: -2, Change: -2}, "Pikachu's Speed harshly fell!"},
+		{StatChange{Stat: "speed", Wanted: -3, Change: -3}, "Pikachu's Speed severely fell!"},
+		{StatChange{Stat: "attack", Wanted: 2}, "Pikachu's Attack won't go any higher!"},
+		{StatChange{Stat: "attack", Wanted: -1}, "Pikachu's Attack won't go any lower!"},
+	}
+
+	for _, tt := range tests {
+		if got := StageMessage("Pikachu", tt.change); got != tt.want {
+			t.Errorf("StageMessage(%+v) = %q, want %q", tt.change, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package battle
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Status conditions. A Pokemon has at most one, kept on its record so it
+// lasts through switching; "" means healthy.
+const (
+	StatusBurn      = "burn"
+	StatusPoison    = "poison"
+	StatusToxic     = "toxic"
+	StatusParalysis = "paralysis"
+	StatusSleep     = "sleep"
+	StatusFreeze    = "freeze"
+)
+
+// statusLabels are the short labels shown next to a fighter's HP.
+var statusLabels = map[string]string{
+	StatusBurn:      "BRN",
+	StatusPoison:    "PSN",
+	StatusToxic:     "TOX",
+	StatusParalysis: "PAR",
+	StatusSleep:     "SLP",
+	StatusFreeze:    "FRZ",
+}
+
+// statusImmunities lists the types that cannot get each status.
+var statusImmunities = map[string][]string{
+	StatusBurn:      {"Fire"},
+	StatusPoison:    {"Poison", "Steel"},
+	StatusToxic:     {"Poison", "Steel"},
+	StatusParalysis: {"Electric"},
+	StatusFreeze:    {"Ice"},
+}
+
+// statusEffect is the status a move inflicts and its chance in percent.
+type statusEffect struct {
+	status string
+	chance int
+}
+
+// moveEffects lists the moves inflicting a status: status moves always do
+// when they hit, damaging moves with the given chance.
+var moveEffects = map[string]statusEffect{
+	"thunder wave":  {StatusParalysis, 100},
+	"stun spore":    {StatusParalysis, 100},
+	"glare":         {StatusParalysis, 100},
+	"nuzzle":        {StatusParalysis, 100},
+	"zap cannon":    {StatusParalysis, 100},
+	"will-o-wisp":   {StatusBurn, 100},
+	"poison powder": {StatusPoison, 100},
+	"poison gas":    {StatusPoison, 100},
+	"toxic":         {StatusToxic, 100},
+	"sleep powder":  {StatusSleep, 100},
+	"spore":         {StatusSleep, 100},
+	"hypnosis":      {StatusSleep, 100},
+	"sing":          {StatusSleep, 100},
+	"lovely kiss":   {StatusSleep, 100},
+	"grass whistle": {StatusSleep, 100},
+	"dark void":     {StatusSleep, 100},
+	"poison fang":   {StatusToxic, 50},
+	"smog":          {StatusPoison, 40},
+	"poison sting":  {StatusPoison, 30},
+	"sludge":        {StatusPoison, 30},
+	"sludge bomb":   {StatusPoison, 30},
+	"poison jab":    {StatusPoison, 30},
+	"gunk shot":     {StatusPoison, 30},
+	"cross poison":  {StatusPoison, 10},
+	"body slam":     {StatusParalysis, 30},
+	"lick":          {StatusParalysis, 30},
+	"spark":         {StatusParalysis, 30},
+	"discharge":     {StatusParalysis, 30},
+	"thunder":       {StatusParalysis, 30},
+	"thunder shock": {StatusParalysis, 10},
+	"thunderbolt":   {StatusParalysis, 10},
+	"thunder punch": {StatusParalysis, 10},
+	"scald":         {StatusBurn, 30},
+	"lava plume":    {StatusBurn, 30},
+	"ember":         {StatusBurn, 10},
+	"flamethrower":  {StatusBurn, 10},
+	"fire blast":    {StatusBurn, 10},
+	"fire punch":    {StatusBurn, 10},
+	"flame wheel":   {StatusBurn, 10},
+	"ice beam":      {StatusFreeze, 10},
+	"blizzard":      {StatusFreeze, 10},
+	"ice punch":     {StatusFreeze, 10},
+	"powder snow":   {StatusFreeze, 10},
+	"freeze-dry":    {StatusFreeze, 10},
+}
+
+// StatusLabel returns the short label of the fighter's status, or "".
+func (f *Fighter) StatusLabel() string {
+	return statusLabels[f.Pokemon.Status]
+}
+
+// Inflict gives the fighter status unless it already has one, has fainted
+// or its type is immune, and reports whether it did.
+func Inflict(f *Fighter, status string, r *rand.Rand) bool {
+	if f.Pokemon.Status != "" || f.Fainted() {
+		return false
+	}
+	for _, t := range statusImmunities[status] {
+		for _, own := range f.Pokemon.Type {
+			if typeName(own) == t {
+				return false
+			}
+		}
+	}
+
+	f.Pokemon.Status = status
+	switch status {
+	case StatusSleep:
+		f.Pokemon.SleepTurns = 1 + r.Intn(3)
+	case StatusToxic:
+		f.toxicTurns = 0
+	}
+	return true
+}
+
+// InflictedMessage describes a status just inflicted on name.
+func InflictedMessage(name, status string) string {
+	switch status {
+	case StatusBurn:
+		return name + " was burned!"
+	case StatusPoison:
+		return name + " was poisoned!"
+	case StatusToxic:
+		return name + " was badly poisoned!"
+	case StatusParalysis:
+		return name + " is paralyzed! It may be unable to move!"
+	case StatusSleep:
+		return name + " fell asleep!"
+	case StatusFreeze:
+		return name + " was frozen solid!"
+	}
+	return ""
+}
+
+// moveEffect returns the status move inflicts, if any.
+func moveEffect(name string) (statusEffect, bool) {
+	effect, ok := moveEffects[strings.ToLower(name)]
+	return effect, ok
+}
+
+// CanMove rolls whether the fighter's status lets it act this turn: a
+// sleeping Pokemon wakes after its sleep turns have passed, a frozen one
+// thaws with a 20% chance and a paralysed one is fully paralysed with a
+// 25% chance. The message, if any, describes what happened.
+func CanMove(f *Fighter, r *rand.Rand) (bool, string) {
+	name := f.Pokemon.Name
+	switch f.Pokemon.Status {
+	case StatusSleep:
+		if f.Pokemon.SleepTurns > 0 {
+			f.Pokemon.SleepTurns--
+			return false, name + " is fast asleep."
+		}
+		f.Pokemon.Status = ""
+		return true, name + " woke up!"
+	case StatusFreeze:
+		if r.Intn(5) == 0 {
+			f.Pokemon.Status = ""
+			return true, name + " thawed out!"
+		}
+		return false, name + " is frozen solid!"
+	case StatusParalysis:
+		if r.Intn(4) == 0 {
+			return false, name + " is paralyzed! It can't move!"
+		}
+	}
+	return true, ""
+}
+
+// Residual applies the end of turn damage of burn and poison: 1/16 of the
+// fighter's maximum HP for a burn, 1/8 for poison, and for a bad poison
+// 1/16 more every turn it stays in. It returns the message to show, or "".
+func Residual(f *Fighter) string {
+	if f.Fainted() {
+		return ""
+	}
+
+	var damage int
+	var cause string
+	switch f.Pokemon.Status {
+	case StatusBurn:
+		damage, cause = f.Stats.HP/16, "its burn"
+	case StatusPoison:
+		damage, cause = f.Stats.HP/8, "poison"
+	case StatusToxic:
+		f.toxicTurns++
+		damage, cause = f.Stats.HP*f.toxicTurns/16, "poison"
+	default:
+		return ""
+	}
+	if damage < 1 {
+		damage = 1
+	}
+
+	f.HP -= damage
+	if f.HP < 0 {
+		f.HP = 0
+	}
+	return fmt.Sprintf("%s is hurt by %s! (-%d HP)", f.Pokemon.Name, cause, damage)
+}
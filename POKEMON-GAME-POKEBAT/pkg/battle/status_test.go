@@ -0,0 +1,142 @@
+package battle
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestInflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		types   []string
+		current string
+		hp      int
+		status  string
+		want    bool
+	}{
+		{"burn", []string{"Water"}, "", 200, StatusBurn, true},
+		{"burn on Fire", []string{"Fire"}, "", 200, StatusBurn, false},
+		{"poison on Poison", []string{"Grass", "Poison"}, "", 200, StatusPoison, false},
+		{"bad poison on Steel", []string{"Steel"}, "", 200, StatusToxic, false},
+		{"paralysis on Electric", []string{"electric"}, "", 200, StatusParalysis, false},
+		{"freeze on Ice", []string{"Ice"}, "", 200, StatusFreeze, false},
+		{"sleep on anything", []string{"Ghost"}, "", 200, StatusSleep, true},
+		{"already has a status", []string{"Water"}, StatusSleep, 200, StatusBurn, false},
+		{"fainted", []string{"Water"}, "", 0, StatusBurn, false},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for _, tt := range tests {
+		f := testFighter("Pokemon", tt.types...)
+		f.Pokemon.Status = tt.current
+		f.HP = tt.hp
+		if got := Inflict(f, tt.status, r); got != tt.want {
+			t.Errorf("%s: Inflict = %v, want %v", tt.name, got, tt.want)
+		}
+		want := tt.current
+		if tt.want {
+			want = tt.status
+		}
+		if f.Pokemon.Status != want {
+			t.Errorf("%s: status = %q, want %q", tt.name, f.Pokemon.Status, want)
+		}
+	}
+
+	// Sleep lasts one to three turns
+	for seed := int64(1); seed <= 50; seed++ {
+		f := testFighter("Pokemon", "Normal")
+		Inflict(f, StatusSleep, rand.New(rand.NewSource(seed)))
+		if f.Pokemon.SleepTurns < 1 || f.Pokemon.SleepTurns > 3 {
+			t.Fatalf("sleep turns = %d, want 1-3", f.Pokemon.SleepTurns)
+		}
+	}
+}
+
+func TestCanMoveSleep(t *testing.T) {
+	f := testFighter("Pokemon", "Normal")
+	f.Pokemon.Status = StatusSleep
+	f.Pokemon.SleepTurns = 2
+
+	r := rand.New(rand.NewSource(1))
+	for turn := 1; turn <= 2; turn++ {
+		if ok, _ := CanMove(f, r); ok {
+			t.Fatalf("turn %d: sleeping Pokemon moved", turn)
+		}
+	}
+	if ok, message := CanMove(f, r); !ok || f.Pokemon.Status != "" || message != "Pokemon woke up!" {
+		t.Errorf("turn 3: moved %v, status %q, message %q", ok, f.Pokemon.Status, message)
+	}
+}
+
+func TestCanMoveChance(t *testing.T) {
+	tests := []struct {
+		status   string
+		min, max int
+	}{
+		// A paralysed Pokemon can't move a quarter of the time
+		{StatusParalysis, 200, 300},
+		// A frozen Pokemon thaws a fifth of the time
+		{StatusFreeze, 750, 850},
+		{StatusBurn, 0, 0},
+		{"", 0, 0},
+	}
+
+	for _, tt := range tests {
+		r := rand.New(rand.NewSource(1))
+		stuck := 0
+		for i := 0; i < 1000; i++ {
+			f := testFighter("Pokemon", "Normal")
+			f.Pokemon.Status = tt.status
+			if ok, _ := CanMove(f, r); !ok {
+				stuck++
+			}
+		}
+		if stuck < tt.min || stuck > tt.max {
+			t.Errorf("status %q: couldn't move %d times in 1000, want %d-%d", tt.status, stuck, tt.min, tt.max)
+		}
+	}
+}
+
+func TestResidual(t *testing.T) {
+	tests := []struct {
+		status string
+		maxHP  int
+		turns  int
+		want   []int
+	}{
+		{StatusBurn, 160, 2, []int{150, 140}},
+		{StatusPoison, 160, 2, []int{140, 120}},
+		{StatusToxic, 160, 3, []int{150, 130, 100}},
+		{StatusBurn, 10, 1, []int{9}},
+		{StatusParalysis, 160, 1, []int{160}},
+		{StatusSleep, 160, 1, []int{160}},
+		{"", 160, 1, []int{160}},
+	}
+
+	for _, tt := range tests {
+		f := testFighter("Pokemon", "Normal")
+		f.Stats.HP, f.HP = tt.maxHP, tt.maxHP
+		f.Pokemon.Status = tt.status
+		for turn, want := range tt.want {
+			message := Residual(f)
+			if f.HP != want {
+				t.Errorf("%q turn %d: HP = %d, want %d", tt.status, turn+1, f.HP, want)
+			}
+			if (message != "") != (want < tt.maxHP) {
+				t.Errorf("%q turn %d: message %q", tt.status, turn+1, message)
+			}
+		}
+	}
+
+	// The damage never takes HP below 0, and a fainted Pokemon takes none
+	f := testFighter("Pokemon", "Normal")
+	f.Pokemon.Status = StatusPoison
+	f.HP = 3
+	Residual(f)
+	if f.HP != 0 {
+		t.Errorf("HP after poison = %d, want 0", f.HP)
+	}
+	if message := Residual(f); message != "" {
+		t.Errorf("fainted Pokemon hurt by poison: %q", message)
+	}
+}
@@ -0,0 +1,113 @@
+// Package battle holds the rules of a Pokemon battle, kept apart from the
+// UDP server in pkg/pokebat that drives it.
+package battle
+
+import "strings"
+
+// typeChart lists, for each attacking type, the defending types it is not
+// neutral against. Missing pairs are neutral (x1).
+var typeChart = map[string]map[string]float64{
+	"Normal": {
+		"Rock": 0.5, "Ghost": 0, "Steel": 0.5,
+	},
+	"Fire": {
+		"Fire": 0.5, "Water": 0.5, "Grass": 2, "Ice": 2, "Bug": 2, "Rock": 0.5, "Dragon": 0.5, "Steel": 2,
+	},
+	"Water": {
+		"Fire": 2, "Water": 0.5, "Grass": 0.5, "Ground": 2, "Rock": 2, "Dragon": 0.5,
+	},
+	"Electric": {
+		"Water": 2, "Electric": 0.5, "Grass": 0.5, "Ground": 0, "Flying": 2, "Dragon": 0.5,
+	},
+	"Grass": {
+		"Fire": 0.5, "Water": 2, "Grass": 0.5, "Poison": 0.5, "Ground": 2, "Flying": 0.5, "Bug": 0.5, "Rock": 2, "Dragon": 0.5, "Steel": 0.5,
+	},
+	"Ice": {
+		"Fire": 0.5, "Water": 0.5, "Grass": 2, "Ice": 0.5, "Ground": 2, "Flying": 2, "Dragon": 2, "Steel": 0.5,
+	},
+	"Fighting": {
+		"Normal": 2, "Ice": 2, "Poison": 0.5, "Flying": 0.5, "Psychic": 0.5, "Bug": 0.5, "Rock": 2, "Ghost": 0, "Dark": 2, "Steel": 2, "Fairy": 0.5,
+	},
+	"Poison": {
+		"Grass": 2, "Poison": 0.5, "Ground": 0.5, "Rock": 0.5, "Ghost": 0.5, "Steel": 0, "Fairy": 2,
+	},
+	"Ground": {
+		"Fire": 2, "Electric": 2, "Grass": 0.5, "Poison": 2, "Flying": 0, "Bug": 0.5, "Rock": 2, "Steel": 2,
+	},
+	"Flying": {
+		"Electric": 0.5, "Grass": 2, "Fighting": 2, "Bug": 2, "Rock": 0.5, "Steel": 0.5,
+	},
+	"Psychic": {
+		"Fighting": 2, "Poison": 2, "Psychic": 0.5, "Dark": 0, "Steel": 0.5,
+	},
+	"Bug": {
+		"Fire": 0.5, "Grass": 2, "Fighting": 0.5, "Poison": 0.5, "Flying": 0.5, "Psychic": 2, "Ghost": 0.5, "Dark": 2, "Steel": 0.5, "Fairy": 0.5,
+	},
+	"Rock": {
+		"Fire": 2, "Ice": 2, "Fighting": 0.5, "Ground": 0.5, "Flying": 2, "Bug": 2, "Steel": 0.5,
+	},
+	"Ghost": {
+		"Normal": 0, "Psychic": 2, "Ghost": 2, "Dark": 0.5,
+	},
+	"Dragon": {
+		"Dragon": 2, "Steel": 0.5, "Fairy": 0,
+	},
+	"Dark": {
+		"Fighting": 0.5, "Psychic": 2, "Ghost": 2, "Dark": 0.5, "Fairy": 0.5,
+	},
+	"Steel": {
+		"Fire": 0.5, "Water": 0.5, "Electric": 0.5, "Ice": 2, "Rock": 2, "Steel": 0.5, "Fairy": 2,
+	},
+	"Fairy": {
+		"Fire": 0.5, "Fighting": 2, "Poison": 0.5, "Dragon": 2, "Dark": 2, "Steel": 0.5,
+	},
+}
+
+// typeName normalises a type as written in the dex or move database,
+// e.g. "fire" to "Fire".
+func typeName(t string) string {
+	t = strings.TrimSpace(t)
+	if t == "" {
+		return t
+	}
+	return strings.ToUpper(t[:1]) + strings.ToLower(t[1:])
+}
+
+// Effectiveness returns the damage multiplier of an attack of type
+// attackType against a Pokemon of the given types: 0 for immune, 0.25 or
+// 0.5 for not very effective, 1 for neutral and 2 or 4 for super effective.
+func Effectiveness(attackType string, defenderTypes []string) float64 {
+	chart := typeChart[typeName(attackType)]
+	multiplier := 1.0
+	for _, t := range defenderTypes {
+		if m, ok := chart[typeName(t)]; ok {
+			multiplier *= m
+		}
+	}
+	return multiplier
+}
+
+// STAB returns the same-type attack bonus: 1.5 when the attack shares a
+// type with its user, 1 otherwise.
+func STAB(attackType string, attackerTypes []string) float64 {
+	for _, t := range attackerTypes {
+		if typeName(t) == typeName(attackType) {
+			return 1.5
+		}
+	}
+	return 1
+}
+
+// EffectivenessMessage is the line shown after an attack with the given
+// multiplier, or "" for a neutral hit.
+func EffectivenessMessage(multiplier float64, defender string) string {
+	switch {
+	case multiplier == 0:
+		return "It doesn't affect " + defender + "..."
+	case multiplier > 1:
+		return "It's super effective!"
+	case multiplier < 1:
+		return "It's not very effective..."
+	}
+	return ""
+}
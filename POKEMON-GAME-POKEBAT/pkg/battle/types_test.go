@@ -0,0 +1,78 @@
+package battle
+
+import "testing"
+
+func TestEffectiveness(t *testing.T) {
+	tests := []struct {
+		attack   string
+		defender []string
+		want     float64
+	}{
+		{"Normal", []string{"Normal"}, 1},
+		{"Fire", []string{"Grass"}, 2},
+		{"Fire", []string{"Water"}, 0.5},
+		{"Water", []string{"Rock", "Ground"}, 4},
+		{"Fire", []string{"Water", "Rock"}, 0.25},
+		{"Grass", []string{"Water", "Poison"}, 1},
+		{"Normal", []string{"Ghost"}, 0},
+		{"Fighting", []string{"Ghost"}, 0},
+		{"Fighting", []string{"Normal", "Ghost"}, 0},
+		{"Ghost", []string{"Normal"}, 0},
+		{"Electric", []string{"Ground"}, 0},
+		{"Electric", []string{"Water", "Ground"}, 0},
+		{"Ground", []string{"Flying"}, 0},
+		{"Psychic", []string{"Dark"}, 0},
+		{"Poison", []string{"Steel"}, 0},
+		{"Dragon", []string{"Fairy"}, 0},
+		{"fire", []string{"grass", "STEEL"}, 4},
+		{" Ice ", []string{"Dragon"}, 2},
+		{"Unknown", []string{"Fire"}, 1},
+		{"Fire", nil, 1},
+	}
+
+	for _, tt := range tests {
+		if got := Effectiveness(tt.attack, tt.defender); got != tt.want {
+			t.Errorf("Effectiveness(%q, %v) = %v, want %v", tt.attack, tt.defender, got, tt.want)
+		}
+	}
+}
+
+func TestSTAB(t *testing.T) {
+	tests := []struct {
+		attack   string
+		attacker []string
+		want     float64
+	}{
+		{"Fire", []string{"Fire"}, 1.5},
+		{"Flying", []string{"Fire", "Flying"}, 1.5},
+		{"water", []string{"Water"}, 1.5},
+		{"Water", []string{"Fire"}, 1},
+		{"Normal", nil, 1},
+	}
+
+	for _, tt := range tests {
+		if got := STAB(tt.attack, tt.attacker); got != tt.want {
+			t.Errorf("STAB(%q, %v) = %v, want %v", tt.attack, tt.attacker, got, tt.want)
+		}
+	}
+}
+
+func TestEffectivenessMessage(t *testing.T) {
+	tests := []struct {
+		multiplier float64
+		want       string
+	}{
+		{0, "It doesn't affect Gengar..."},
+		{0.25, "It's not very effective..."},
+		{0.5, "It's not very effective..."},
+		{1, ""},
+		{2, "It's super effective!"},
+		{4, "It's super effective!"},
+	}
+
+	for _, tt := range tests {
+		if got := EffectivenessMessage(tt.multiplier, "Gengar"); got != tt.want {
+			t.Errorf("EffectivenessMessage(%v) = %q, want %q", tt.multiplier, got, tt.want)
+		}
+	}
+}
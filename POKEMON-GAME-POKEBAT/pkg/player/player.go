@@ -1,23 +1,129 @@
 package player
 
+import (
+	"fmt"
+	"time"
+
+	"pokemon-game/dex"
+)
+
+// Player is a trainer with their Pokemon, the bag of items they carry
+// into battle, by item name and count, and their battle record.
 type Player struct {
 	ID       int               `json:"id"`
 	Name     string            `json:"name"`
 	Pokemons []CapturedPokemon `json:"pokemon_list"`
+	Bag      map[string]int    `json:"bag,omitempty"`
+	Wins     int               `json:"wins,omitempty"`
+	Losses   int               `json:"losses,omitempty"`
+	History  []BattleRecord    `json:"battle_history,omitempty"`
+}
+
+// MaxHistory is the number of battles kept in a player's history.
+const MaxHistory = 50
+
+// BattleRecord is one battle in a player's history. Result is "win" or
+// "loss" and Seed replays the battle with the -seed flag of the server.
+type BattleRecord struct {
+	Opponent string    `json:"opponent"`
+	Result   string    `json:"result"`
+	Forfeit  bool      `json:"forfeit,omitempty"`
+	Turns    int       `json:"turns"`
+	Seed     int64     `json:"seed"`
+	Time     time.Time `json:"time"`
+}
+
+// AddBattle counts a battle as a win or loss and adds it to the history,
+// dropping the oldest battles past MaxHistory.
+func (p *Player) AddBattle(r BattleRecord) {
+	if r.Result == "win" {
+		p.Wins++
+	} else {
+		p.Losses++
+	}
+	p.History = append(p.History, r)
+	if len(p.History) > MaxHistory {
+		p.History = p.History[len(p.History)-MaxHistory:]
+	}
 }
 
 type CapturedPokemon struct {
-	ID         int      `json:"id"`
-	Name       string   `json:"name"`
-	Type       []string `json:"type"`
-	BaseExp    int      `json:"base_exp"`
-	HP         int      `json:"hp"`
-	EV         float64  `json:"ev"`
-	Level      int      `json:"level"`
-	CurrentExp int      `json:"current_exp"`
-	Speed      int      `json:"speed"`
-	Attack     int      `json:"attack"`
-	Defense    int      `json:"defense"`
-	SpecialAtk int      `json:"special_atk"`
-	SpecialDef int      `json:"special_def"`
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	Type       []string     `json:"type"`
+	BaseExp    int          `json:"base_exp"`
+	HP         int          `json:"hp"`
+	EV         float64      `json:"ev"`
+	EVYield    *dex.EVYield `json:"ev_yield,omitempty"`
+	Level      int          `json:"level"`
+	CurrentExp int          `json:"current_exp"`
+	Speed      int          `json:"speed"`
+	Attack     int          `json:"attack"`
+	Defense    int          `json:"defense"`
+	SpecialAtk int          `json:"special_atk"`
+	SpecialDef int          `json:"special_def"`
+	GrowthRate string       `json:"growth_rate,omitempty"`
+	IVs        *dex.Stats   `json:"ivs,omitempty"`
+	EVs        *dex.Stats   `json:"evs,omitempty"`
+	Nature     string       `json:"nature,omitempty"`
+	Status     string       `json:"status,omitempty"`
+	SleepTurns int          `json:"sleep_turns,omitempty"`
+	HeldItem   string       `json:"held_item,omitempty"`
+}
+
+// BaseStats returns the species base stats stored on the record.
+func (p CapturedPokemon) BaseStats() dex.Stats {
+	return dex.Stats{
+		HP:         p.HP,
+		Attack:     p.Attack,
+		Defense:    p.Defense,
+		SpecialAtk: p.SpecialAtk,
+		SpecialDef: p.SpecialDef,
+		Speed:      p.Speed,
+	}
+}
+
+// Stats returns the actual stats of the Pokemon at its level. Records
+// captured before IVs, EVs and natures were tracked count them as zero
+// and neutral.
+func (p CapturedPokemon) Stats() dex.Stats {
+	var ivs, evs dex.Stats
+	if p.IVs != nil {
+		ivs = *p.IVs
+	}
+	if p.EVs != nil {
+		evs = *p.EVs
+	}
+	return dex.CalcStats(p.BaseStats(), ivs, evs, p.Level, dex.LookupNature(p.Nature))
+}
+
+// EVSummary describes the EV yield of the Pokemon, falling back to the
+// plain total for records saved before per-stat yields were tracked.
+func (p CapturedPokemon) EVSummary() string {
+	if p.EVYield != nil {
+		return p.EVYield.String()
+	}
+	return fmt.Sprintf("%.1f", p.EV)
+}
+
+// Evolve turns the Pokemon into species, keeping its level, experience,
+// IVs, EVs and nature.
+func (p *CapturedPokemon) Evolve(species dex.Pokemon) {
+	p.Name = species.Name
+	p.Type = species.Type
+	p.BaseExp = species.BaseExp
+	p.HP = species.HP
+	p.Attack = species.Attack
+	p.Defense = species.Defense
+	p.SpecialAtk = species.SpecialAtk
+	p.SpecialDef = species.SpecialDef
+	p.Speed = species.Speed
+	p.EV = species.EV
+	if species.EVYield.Total() > 0 {
+		yield := species.EVYield
+		p.EVYield = &yield
+	}
+	if species.GrowthRate != "" {
+		p.GrowthRate = species.GrowthRate
+	}
 }
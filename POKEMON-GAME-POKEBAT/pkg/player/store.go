@@ -0,0 +1,108 @@
+package player
+
+import (
+	"fmt"
+	"sync"
+
+	"POKEMON-GAME-POKEBAT/pkg/utils"
+
+	"pokemon-game/dex"
+)
+
+// Store holds the player records of a player file and writes every change
+// back to it. It is safe for use by concurrent battles: updates are made
+// one at a time, each rewriting the whole file atomically, so a crash
+// leaves either the old or the new file and no battle undoes another's
+// results. The zero Store has no players.
+type Store struct {
+	file string
+
+	mu      sync.Mutex
+	players []Player
+	byName  map[string]int
+}
+
+// LoadStore reads the player file at file, of any supported schema
+// version.
+func LoadStore(file string) (*Store, error) {
+	var players []Player
+	if err := utils.LoadVersioned(file, dex.PlayerListKey, &players); err != nil {
+		return nil, err
+	}
+
+	s := &Store{file: file, players: players, byName: make(map[string]int, len(players))}
+	for i, p := range players {
+		s.byName[p.Name] = i
+	}
+	return s, nil
+}
+
+// Len returns the number of players.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.players)
+}
+
+// Get returns a copy of the record of the player called name.
+func (s *Store) Get(name string) (Player, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byName[name]
+	if !ok {
+		return Player{}, false
+	}
+	return s.players[i].clone(), true
+}
+
+// Update applies update to the record of the player called name and saves
+// the file. If the file can't be written the record is left unchanged.
+func (s *Store) Update(name string, update func(p *Player)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i, ok := s.byName[name]
+	if !ok {
+		return fmt.Errorf("no player found with name %q", name)
+	}
+	old := s.players[i]
+	p := old.clone()
+	update(&p)
+
+	s.players[i] = p
+	if err := utils.SaveVersioned(s.file, dex.PlayerListKey, s.players); err != nil {
+		s.players[i] = old
+		return err
+	}
+	return nil
+}
+
+// clone returns a copy of p sharing no slices or maps with it.
+func (p Player) clone() Player {
+	c := p
+	c.Pokemons = append([]CapturedPokemon(nil), p.Pokemons...)
+	for i, pokemon := range c.Pokemons {
+		c.Pokemons[i].Type = append([]string(nil), pokemon.Type...)
+		if pokemon.EVYield != nil {
+			yield := *pokemon.EVYield
+			c.Pokemons[i].EVYield = &yield
+		}
+		if pokemon.IVs != nil {
+			ivs := *pokemon.IVs
+			c.Pokemons[i].IVs = &ivs
+		}
+		if pokemon.EVs != nil {
+			evs := *pokemon.EVs
+			c.Pokemons[i].EVs = &evs
+		}
+	}
+	if p.Bag != nil {
+		c.Bag = make(map[string]int, len(p.Bag))
+		for name, count := range p.Bag {
+			c.Bag[name] = count
+		}
+	}
+	c.History = append([]BattleRecord(nil), p.History...)
+	return c
+}
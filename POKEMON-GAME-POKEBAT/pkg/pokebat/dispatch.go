@@ -0,0 +1,189 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// inboxSize is how many unread messages a session holds before dropping
+// more.
+const inboxSize = 32
+
+// datagram is a message received from a client.
+type datagram struct {
+	message string
+	addr    *net.UDPAddr
+}
+
+// session is a logged in player. The dispatcher delivers every message
+// from the player's address to inbox, so a prompt only ever reads the
+// replies of the player it was sent to.
+type session struct {
+	name  string
+	addr  *net.UDPAddr
+	inbox chan string
+	done  chan struct{}
+}
+
+// receive waits for the player's next message until deadline, or for as
+// long as the session is open with a zero deadline. It reports false if
+// the deadline passes or the session is closed first.
+func (s *session) receive(deadline time.Time) (string, bool) {
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case message := <-s.inbox:
+		return message, true
+	case <-s.done:
+		// Messages sent before logging out still count
+		select {
+		case message := <-s.inbox:
+			return message, true
+		default:
+			return "", false
+		}
+	case <-timeout:
+		return "", false
+	}
+}
+
+// closed reports whether the player has logged out.
+func (s *session) closed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// flush drops the messages the player sent before being asked anything,
+// so an early or repeated reply doesn't answer the next prompt.
+func (s *session) flush() {
+	for {
+		select {
+		case <-s.inbox:
+		default:
+			return
+		}
+	}
+}
+
+// dispatcher owns the server socket. It is the only reader of it and
+// routes each datagram by its sender: to the inbox of the sender's session,
+// or to control for logins, logouts and messages from unknown clients.
+type dispatcher struct {
+	conn    *net.UDPConn
+	control chan datagram
+
+	mu     sync.Mutex
+	byAddr map[string]*session
+	byName map[string]*session
+}
+
+func newDispatcher(conn *net.UDPConn) *dispatcher {
+	return &dispatcher{
+		conn:    conn,
+		control: make(chan datagram, inboxSize),
+		byAddr:  make(map[string]*session),
+		byName:  make(map[string]*session),
+	}
+}
+
+// run reads the socket until it is closed.
+func (d *dispatcher) run() {
+	buf := make([]byte, 1024)
+	for {
+		n, addr, err := d.conn.ReadFromUDP(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				close(d.control)
+				return
+			}
+			fmt.Println("Error reading from UDP:", err)
+			continue
+		}
+		message := string(buf[:n])
+		fmt.Println("Received message from client: ", message)
+
+		if strings.HasPrefix(message, "LOGIN:") || strings.HasPrefix(message, "LOGOUT:") {
+			d.control <- datagram{message: message, addr: addr}
+			continue
+		}
+		if s := d.session(addr); s != nil {
+			select {
+			case s.inbox <- message:
+			default:
+				fmt.Printf("Inbox of %s full, dropping message: %s\n", s.name, message)
+			}
+			continue
+		}
+		d.control <- datagram{message: message, addr: addr}
+	}
+}
+
+// session returns the session of the client at addr, or nil.
+func (d *dispatcher) session(addr *net.UDPAddr) *session {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.byAddr[addr.String()]
+}
+
+// open starts a session for the player called name at addr, reporting
+// false if the player is already logged in.
+func (d *dispatcher) open(name string, addr *net.UDPAddr) (*session, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.byName[name]; ok {
+		return nil, false
+	}
+	if old, ok := d.byAddr[addr.String()]; ok {
+		d.remove(old)
+	}
+	s := &session{name: name, addr: addr, inbox: make(chan string, inboxSize), done: make(chan struct{})}
+	d.byAddr[addr.String()] = s
+	d.byName[name] = s
+	return s, true
+}
+
+// close ends the session of the player called name if it was opened from
+// addr, so a client can only log out its own player. It reports whether
+// it ended a session.
+func (d *dispatcher) close(name string, addr *net.UDPAddr) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, ok := d.byName[name]
+	if !ok || s.addr.String() != addr.String() {
+		return false
+	}
+	d.remove(s)
+	return true
+}
+
+// end ends s unless it has already ended.
+func (d *dispatcher) end(s *session) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.byName[s.name] == s {
+		d.remove(s)
+	}
+}
+
+// remove forgets s and wakes up anyone waiting on it. d.mu must be held.
+func (d *dispatcher) remove(s *session) {
+	delete(d.byAddr, s.addr.String())
+	delete(d.byName, s.name)
+	close(s.done)
+}
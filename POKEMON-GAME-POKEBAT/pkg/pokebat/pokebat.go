@@ -1,127 +1,142 @@
 package main
 
 import (
+	"POKEMON-GAME-POKEBAT/pkg/battle"
 	"POKEMON-GAME-POKEBAT/pkg/player"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math/rand"
 	"net"
-	"os"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
-)
-
-type Player struct {
-	ID       int               `json:"id"`
-	Name     string            `json:"name"`
-	Pokemons []CapturedPokemon `json:"pokemon_list"`
-}
 
-type CapturedPokemon struct {
-	ID         int      `json:"id"`
-	Name       string   `json:"name"`
-	Type       []string `json:"type"`
-	BaseExp    int      `json:"base_exp"`
-	HP         int      `json:"hp"`
-	EV         float64  `json:"ev"`
-	Level      int      `json:"level"`
-	CurrentExp int      `json:"current_exp"`
-	Speed      int      `json:"speed"`
-	Attack     int      `json:"attack"`
-	Defense    int      `json:"defense"`
-	SpecialAtk int      `json:"special_atk"`
-	SpecialDef int      `json:"special_def"`
-}
+	"pokemon-game/dex"
+)
 
 type gamer struct {
 	name        string
-	fighterList map[int]player.CapturedPokemon
-	fighter     player.CapturedPokemon
+	fighterList map[int]*battle.Fighter
+	fighter     *battle.Fighter
 	addr        *net.UDPAddr
+	session     *session
+	bag         map[string]int //Items left in the player's bag, by item key
 }
 
 var (
-	players      = make(map[string]player.Player)
-	gamers       = make(map[string]*gamer) //Store pointers to gamers
-	mutex        sync.Mutex
-	battleActive bool
+	players      = &player.Store{} //Player records, saved after every battle
 	serverConn   *net.UDPConn
+	clients      *dispatcher //Routes each client's messages to their session
 	divider      = "________________________-"
+	seed         int64 //Seed of every battle's random rolls, 0 for a fresh one each battle
+	turnTimeout  = time.Minute
+	pokedex      *dex.Dex
+	movedex      map[string]dex.Move
 )
 
-func parseInput(input string, maxChoices int) []int {
-    choices := []int{}
-    for _, char := range strings.Fields(input) {
-        choice, err := strconv.Atoi(char)
-        // Check valid selection input
-        if err != nil || choice < 1 || choice > maxChoices {
-            continue
-        }
-        choices = append(choices, choice)
+// Parse a team selection of exactly size Pokemon IDs out of pokemons,
+// rejecting unknown and repeated IDs
+func parseTeam(input string, pokemons []player.CapturedPokemon, size int) ([]player.CapturedPokemon, error) {
+    byID := make(map[int]player.CapturedPokemon, len(pokemons))
+    for _, pokemon := range pokemons {
+        byID[pokemon.ID] = pokemon
     }
-    return choices
-}
 
-func switchTurn(attacker, defender *gamer) {
-    *attacker, *defender = *defender, *attacker
+    var team []player.CapturedPokemon
+    chosen := make(map[int]bool)
+    for _, field := range strings.Fields(input) {
+        id, err := strconv.Atoi(field)
+        if err != nil {
+            return nil, fmt.Errorf("%q is not a Pokemon ID", field)
+        }
+        pokemon, ok := byID[id]
+        if !ok {
+            return nil, fmt.Errorf("you have no Pokemon with ID %d", id)
+        }
+        if chosen[id] {
+            return nil, fmt.Errorf("you chose Pokemon %d more than once", id)
+        }
+        chosen[id] = true
+        team = append(team, pokemon)
+    }
+    if len(team) != size {
+        return nil, fmt.Errorf("please select exactly %d Pokemon", size)
+    }
+    return team, nil
 }
 
 func wait(i int) {
     time.Sleep(time.Duration(i) * time.Second)
 }
 
-func choosePokemon(g gamer, p player.Player, conn *net.UDPConn) []player.CapturedPokemon {
-    // Create list to store chosen pokemons
-    var chosenPokemons []player.CapturedPokemon
-
+// Ask a player for the three Pokemon of their team, reporting false if they
+// logged out before answering
+func choosePokemon(g gamer, p player.Player, conn *net.UDPConn) ([]player.CapturedPokemon, bool) {
     // Display player's name and pokemon list
     sendMessage(conn, g.addr, fmt.Sprintf("Player: %s\n", g.name))
     for _, pokemon := range p.Pokemons {
         sendMessage(conn, g.addr, showPokemonProfile(pokemon))
     }
 
-    sendMessage(conn, g.addr, "Select three Pokemon (Please enter the pokemon ID separated by space):")
-
-    // Receive and analyze selections from client
-    message := receiveMessage(conn)
-    fmt.Println("Received message:", message)
-    choices := parseInput(message, len(p.Pokemons))
+    for {
+        g.session.flush()
+        sendMessage(conn, g.addr, "Select three Pokemon (Please enter the pokemon ID separated by space):")
 
-    // Validate the number of choices
-    if len(choices) != 3 {
-        sendMessage(conn, g.addr, "BAD INPUT: Please select exactly three Pokemon.")
-        return choosePokemon(g, p, conn)
-    }
+        // Receive and analyze selections from client
+        message, ok := g.session.receive(time.Time{})
+        if !ok {
+            return nil, false
+        }
+        fmt.Println("Received message:", message)
 
-    // Append each selected pokemon into chosen list
-    for _, choice := range choices {
-        if choice < 1 || choice > len(p.Pokemons) {
-            sendMessage(conn, g.addr, "BAD INPUT: Invalid Pokemon ID.")
-            return choosePokemon(g, p, conn)
+        chosenPokemons, err := parseTeam(message, p.Pokemons, 3)
+        if err != nil {
+            sendMessage(conn, g.addr, "BAD INPUT: "+err.Error())
+            continue
         }
-        chosenPokemons = append(chosenPokemons, p.Pokemons[choice-1])
+        fmt.Println(chosenPokemons)
+        return chosenPokemons, true
     }
-
-    fmt.Println(chosenPokemons)
-    return chosenPokemons
 }
 
 func showPokemonProfile(pokemon player.CapturedPokemon) string {
+    stats := pokemon.Stats()
     profile := fmt.Sprintf("%d. Name: %s | ", pokemon.ID, pokemon.Name)
     profile += fmt.Sprintf("Type: %v | ", pokemon.Type)
     profile += fmt.Sprintf("Base Exp: %d |", pokemon.BaseExp)
-    profile += fmt.Sprintf("HP: %d | ", pokemon.HP)
-    profile += fmt.Sprintf("EV: %.1f | ", pokemon.EV)
+    profile += fmt.Sprintf("HP: %d | ", stats.HP)
+    profile += fmt.Sprintf("EV: %s | ", pokemon.EVSummary())
     profile += fmt.Sprintf("Level: %d | ", pokemon.Level)
     profile += fmt.Sprintf("Current Exp: %d\n", pokemon.CurrentExp)
-    profile += fmt.Sprintf("Speed: %d | ", pokemon.Speed)
-    profile += fmt.Sprintf("Attack: %d | ", pokemon.Attack)
-    profile += fmt.Sprintf("Defense: %d | ", pokemon.Defense)
-    profile += fmt.Sprintf("Special Atk: %d | ", pokemon.SpecialAtk)
-    profile += fmt.Sprintf("Special Def: %d\n", pokemon.SpecialDef)
+    profile += fmt.Sprintf("Speed: %d | ", stats.Speed)
+    profile += fmt.Sprintf("Attack: %d | ", stats.Attack)
+    profile += fmt.Sprintf("Defense: %d | ", stats.Defense)
+    profile += fmt.Sprintf("Special Atk: %d | ", stats.SpecialAtk)
+    profile += fmt.Sprintf("Special Def: %d\n", stats.SpecialDef)
+    profile += "\n"
+    return profile
+}
+
+// Show a fighter with its remaining HP and its stats at its level
+func showFighterProfile(f *battle.Fighter) string {
+    profile := fmt.Sprintf("%d. Name: %s | ", f.Pokemon.ID, f.Pokemon.Name)
+    profile += fmt.Sprintf("Type: %v | ", f.Pokemon.Type)
+    profile += fmt.Sprintf("Level: %d | ", f.Pokemon.Level)
+    profile += fmt.Sprintf("HP: %d/%d", f.HP, f.Stats.HP)
+    if label := f.StatusLabel(); label != "" {
+        profile += " " + label
+    }
+    if f.Pokemon.HeldItem != "" {
+        profile += fmt.Sprintf(" | Holding: %s", f.Pokemon.HeldItem)
+    }
+    profile += "\n"
+    profile += fmt.Sprintf("Speed: %d | ", f.Stats.Speed)
+    profile += fmt.Sprintf("Attack: %d | ", f.Stats.Attack)
+    profile += fmt.Sprintf("Defense: %d | ", f.Stats.Defense)
+    profile += fmt.Sprintf("Special Atk: %d | ", f.Stats.SpecialAtk)
+    profile += fmt.Sprintf("Special Def: %d\n", f.Stats.SpecialDef)
     profile += "\n"
     return profile
 }
@@ -138,244 +153,560 @@ func sendMessage(conn *net.UDPConn, addr *net.UDPAddr, message string) {
     }
 }
 
-// Receive message from client
-func receiveMessage(conn *net.UDPConn) string {
-    buffer := make([]byte, 1024)
-    n, _, err := conn.ReadFromUDP(buffer)
-    if err != nil {
-        fmt.Println("Error reading from UDP:", err)
-        return ""
-    }
-    return string(buffer[:n])
+// Send message to both players of a battle
+func sendBoth(gamer1, gamer2 *gamer, message string) {
+    sendMessage(serverConn, gamer1.addr, message)
+    sendMessage(serverConn, gamer2.addr, message)
 }
 
-func handleClient(conn *net.UDPConn, addr *net.UDPAddr, playerName string) {
-    //Ensure multiple client can connect to server
-    mutex.Lock()
-    defer mutex.Unlock()
-
-    p, exist := players[playerName]
+// Register a logged in player: they choose their team, then join the
+// lobby to wait for an opponent. Their replies come from their session, so
+// any number of players can choose their teams at once.
+func handleClient(conn *net.UDPConn, s *session) {
+    playerName, addr := s.name, s.addr
+    p, exist := players.Get(playerName)
     if !exist {
-        sendMessage(conn, addr, "ERROR: No player found with name: "+playerName)
+        sendMessage(conn, addr, "LOGGED OUT: No player found with name: "+playerName)
+        clients.end(s)
+        return
     }
 
     g := &gamer{
         name:        playerName,
-        fighterList: make(map[int]player.CapturedPokemon),
+        fighterList: make(map[int]*battle.Fighter),
         addr:        addr,
+        session:     s,
+        bag:         make(map[string]int),
+    }
+    for name, count := range p.Bag {
+        g.bag[battle.ItemKey(name)] += count
     }
 
-    choosePokemons := choosePokemon(*g, p, conn)
+    choosePokemons, ok := choosePokemon(*g, p, conn)
+    if !ok {
+        fmt.Println("Player left before choosing a team: " + playerName)
+        return
+    }
     for _, pokemon := range choosePokemons {
-        g.fighterList[pokemon.ID] = pokemon
+        if pokemon.GrowthRate == "" {
+            pokemon.GrowthRate = growthRate(pokemon)
+        }
+        g.fighterList[pokemon.ID] = battle.NewFighter(pokemon, fighterMoves(pokemon))
     }
 
-    g.fighter = choosePokemons[0]
-    gamers[playerName] = g
+    g.fighter = g.fighterList[choosePokemons[0].ID]
     sendMessage(conn, addr, "SUCCESS: You have registered with name: "+playerName)
     fmt.Println("Player registered: " + playerName)
+    rooms.join(g)
 }
 
-func selectFighter(g *gamer, conn *net.UDPConn) bool {
+// Ask a player whose fighter fainted to send in another one, reporting
+// false if they have none left, forfeit or logged out. A player who hasn't
+// answered within turnTimeout sends in their first fighter still standing.
+func selectFighter(g *gamer) bool {
     // Variable to verify if there are available fighters
     available := false
 
-    for _, pokemon := range g.fighterList {
-        if pokemon.HP > 0 {
+    ids := make([]int, 0, len(g.fighterList))
+    for id, f := range g.fighterList {
+        if !f.Fainted() {
             available = true
-            sendMessage(conn, g.addr, showPokemonProfile(pokemon))
+            ids = append(ids, id)
+            sendMessage(serverConn, g.addr, showFighterProfile(f))
         }
     }
 
     // If user doesn't have any available pokemon
     if !available {
-        sendMessage(conn, g.addr, "You don't have any available fighter left!")
+        sendMessage(serverConn, g.addr, "You don't have any available fighter left!")
         return false
     }
+    sort.Ints(ids)
 
     // If user has available pokemon
+    g.session.flush()
+    sendMessage(serverConn, g.addr, "Select your fighter by ID: ")
+    deadline := time.Now().Add(turnTimeout)
     for {
-        sendMessage(conn, g.addr, "Select your fighter by ID: ")
-        input := receiveMessage(conn)
-        id, err := strconv.Atoi(strings.TrimSpace(input))
-        if err != nil || id < 1 || id > len(g.fighterList) {
-            sendMessage(conn, g.addr, "BAD INPUT: Invalid input. Please enter a valid Pokemon ID.")
+        message, ok := g.session.receive(deadline)
+        if !ok && g.session.closed() {
+            return false
+        }
+        if !ok {
+            g.fighter = g.fighterList[ids[0]]
+            sendMessage(serverConn, g.addr, fmt.Sprintf("Time is up! Selected fighter: %s\n", g.fighter.Pokemon.Name))
+            return true
+        }
+
+        input := strings.TrimSpace(message)
+        if action, err := battle.ParseAction(input); err == nil && action.Kind == battle.ActionForfeit {
+            return false
+        }
+        id, err := strconv.Atoi(input)
+        if err != nil {
+            sendMessage(serverConn, g.addr, "BAD INPUT: Invalid input. Please enter a valid Pokemon ID.")
+            sendMessage(serverConn, g.addr, "Select your fighter by ID: ")
             continue
         }
 
         selectedPokemon, ok := g.fighterList[id]
         // Check if the selected pokemon is valid
-        if !ok || selectedPokemon.HP <= 0 {
-            sendMessage(conn, g.addr, "BAD SELECTION: Invalid ID or your selected pokemon has fainted.")
+        if !ok || selectedPokemon.Fainted() {
+            sendMessage(serverConn, g.addr, "BAD SELECTION: Invalid ID or your selected pokemon has fainted.")
+            sendMessage(serverConn, g.addr, "Select your fighter by ID: ")
             continue
         }
 
         g.fighter = selectedPokemon
-        sendMessage(conn, g.addr, fmt.Sprintf("Selected fighter: %s\n", g.fighter.Name))
+        sendMessage(serverConn, g.addr, fmt.Sprintf("Selected fighter: %s\n", g.fighter.Pokemon.Name))
         return true
     }
 }
 
-func startBattle(gamer1, gamer2 *gamer) {
-    fmt.Println("Battle begins!")
-
-    sendMessage(serverConn, gamer1.addr, "Two players connected. The battle is starting!\n")
-    sendMessage(serverConn, gamer2.addr, "Two players connected. The battle is starting!\n")
+// Run the battle of a room until one of its players wins
+func startBattle(r *room) {
+    gamer1, gamer2 := r.gamer1, r.gamer2
 
-    var attacker, defender *gamer
-    if gamer1.fighter.Speed >= gamer2.fighter.Speed {
-        attacker = gamer1
-        defender = gamer2
-    } else {
-        attacker = gamer2
-        defender = gamer1
+    // Log the seed so the battle can be replayed with -seed
+    battleSeed := seed
+    if battleSeed == 0 {
+        battleSeed = time.Now().UnixNano()
     }
+    rng := rand.New(rand.NewSource(battleSeed))
+    fmt.Printf("Room %d: battle begins! Seed: %d\n", r.id, battleSeed)
+
+    sendBoth(gamer1, gamer2, "Two players connected. The battle is starting!\n")
+
+    var winner, loser *gamer
+    forfeit := false
+    turn := 0
+    for winner == nil {
+        turn++
+        sendBoth(gamer1, gamer2, divider)
+        sendBoth(gamer1, gamer2, fmt.Sprintf("Turn %d: %s's %s vs %s's %s\n", turn,
+            gamer1.name, gamer1.fighter.Pokemon.Name, gamer2.name, gamer2.fighter.Pokemon.Name))
+
+        battle.Face(gamer1.fighter, gamer2.fighter)
+        actions := collectActions(r)
+
+        // Carry out both actions in turn order: switches before moves,
+        // then by move priority and the current speed of the fighters
+        order := []*gamer{gamer1, gamer2}
+        if !battle.GoesFirst(actions[gamer1], gamer1.fighter, actions[gamer2], gamer2.fighter, rng) {
+            order = []*gamer{gamer2, gamer1}
+        }
+        for _, g := range order {
+            foe := opponent(g, gamer1, gamer2)
+            if forfeited := takeAction(g, foe, actions[g], rng); forfeited {
+                winner, loser = foe, g
+                forfeit = true
+                break
+            }
+        }
+        if winner != nil {
+            break
+        }
 
-    i := 0
-    for {
-        i++
-        sendMessage(serverConn, defender.addr, divider)
-        sendMessage(serverConn, attacker.addr, divider)
-        sendMessage(serverConn, attacker.addr, fmt.Sprintf("Turn %d, attacker %s: \n", i, attacker.name))
-        sendMessage(serverConn, defender.addr, fmt.Sprintf("Turn %d, attacker %s: \n", i, attacker.name))
+        // Burn and poison hurt at the end of the turn, then held items work
+        for _, g := range order {
+            if message := battle.Residual(g.fighter); message != "" {
+                sendBoth(gamer1, gamer2, message)
+            }
+            if message := battle.HeldItemEffect(g.fighter); message != "" {
+                sendBoth(gamer1, gamer2, message)
+            }
+        }
 
-        attack(attacker, defender)
+        sendBoth(gamer1, gamer2, "Turn result: ")
+        sendBoth(gamer1, gamer2, showFighterProfile(gamer1.fighter)+showFighterProfile(gamer2.fighter))
 
-        sendMessage(serverConn, attacker.addr, "Turn result: ")
-        sendMessage(serverConn, attacker.addr, showPokemonProfile(defender.fighter))
+        // Fainted fighters give their experience to the fighters that faced
+        // them and have to be replaced; a player with none left loses
+        for _, g := range order {
+            if !g.fighter.Fainted() {
+                continue
+            }
+            foe := opponent(g, gamer1, gamer2)
+            sendMessage(serverConn, g.addr, fmt.Sprintf("%s's %s fainted!\n, you have to switch your fighter!", g.name, g.fighter.Pokemon.Name))
+            sendMessage(serverConn, foe.addr, "The opponent's fighter is fainted!, wait for them to switch the fighter!")
+            distributedExperiencePoints(foe, g, serverConn)
 
-        sendMessage(serverConn, defender.addr, "Turn result: ")
-        sendMessage(serverConn, defender.addr, showPokemonProfile(defender.fighter))
+            if !selectFighter(g) {
+                winner, loser = foe, g
+                break
+            }
+        }
+    }
 
-        sendMessage(serverConn, defender.addr, divider)
-        sendMessage(serverConn, attacker.addr, divider)
+    // Both players keep the experience and levels their Pokemon earned
+    played := time.Now()
+    recordBattle(winner, loser, player.BattleRecord{Opponent: loser.name, Result: "win", Forfeit: forfeit, Turns: turn, Seed: battleSeed, Time: played})
+    recordBattle(loser, winner, player.BattleRecord{Opponent: winner.name, Result: "loss", Forfeit: forfeit, Turns: turn, Seed: battleSeed, Time: played})
+    sendMessage(serverConn, loser.addr, "END BATTLE: YOU LOST!!!")
+    sendMessage(serverConn, winner.addr, "END BATTLE: YOU WIN!!!")
+    sendBoth(gamer1, gamer2, "BATTLE ENDED!")
+}
 
-        //If fighter of defender runs out of blood
-        if defender.fighter.HP <= 0 {
-            sendMessage(serverConn, defender.addr, divider)
-            sendMessage(serverConn, attacker.addr, divider)
+// Return the other player of a battle
+func opponent(g, gamer1, gamer2 *gamer) *gamer {
+    if g == gamer1 {
+        return gamer2
+    }
+    return gamer1
+}
 
-            sendMessage(serverConn, defender.addr, fmt.Sprintf("%s's %s fainted!\n, you have to switch your fighter!", defender.name, defender.fighter.Name))
-            sendMessage(serverConn, attacker.addr, "The opponent's fighter is fainted!, wait for them to switch the fighter!")
+// Prompt both players for their action and wait for both answers, up to
+// turnTimeout. A player who hasn't answered in time uses their first move.
+func collectActions(r *room) map[*gamer]battle.Action {
+    gamer1, gamer2 := r.gamer1, r.gamer2
+    actions := make(map[*gamer]battle.Action)
+    for _, g := range []*gamer{gamer1, gamer2} {
+        g.session.flush()
+        sendMessage(serverConn, g.addr, actionPrompt(g))
+    }
 
-            status := selectFighter(defender, serverConn)
-            //Attacker win the battle
-            if !status {
-                distributedExperiencePoints(attacker, defender, serverConn)
-                sendMessage(serverConn, defender.addr, "END BATTLE: YOU LOST!!!")
-                sendMessage(serverConn, defender.addr, "END BATTLE: YOU WIN!!!")
-                break
+    deadline := time.Now().Add(turnTimeout)
+    for len(actions) < 2 {
+        g, message, ok := r.receive(deadline)
+        if !ok {
+            break
+        }
+        // A player who logged out forfeits, which ends the battle before
+        // any move is made
+        if g.session.closed() {
+            actions[g] = battle.Action{Kind: battle.ActionForfeit}
+            if _, chosen := actions[opponent(g, gamer1, gamer2)]; !chosen {
+                actions[opponent(g, gamer1, gamer2)] = battle.Action{Kind: battle.ActionMove}
             }
+            return actions
+        }
+        if _, chosen := actions[g]; chosen {
+            sendMessage(serverConn, g.addr, "You have already chosen your action, waiting for your opponent...")
+            continue
+        }
+
+        action, err := checkAction(g, message)
+        if err != nil {
+            sendMessage(serverConn, g.addr, "BAD INPUT: "+err.Error())
+            continue
         }
+        actions[g] = action
+        sendMessage(serverConn, g.addr, "Waiting for your opponent...")
+    }
 
-        sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s, do you want to switch your fighter? (Y/N)", attacker.name))
+    for _, g := range []*gamer{gamer1, gamer2} {
+        if _, chosen := actions[g]; !chosen {
+            sendMessage(serverConn, g.addr, fmt.Sprintf("Time is up! %s uses %s.", g.fighter.Pokemon.Name, g.fighter.Moves[0].Name))
+            actions[g] = battle.Action{Kind: battle.ActionMove}
+        }
+    }
+    return actions
+}
 
+// List the moves and team of a player along with the actions they can take
+func actionPrompt(g *gamer) string {
+    prompt := fmt.Sprintf("What will %s do? You have %d seconds to answer.\n", g.fighter.Pokemon.Name, int(turnTimeout/time.Second))
+    for i, m := range g.fighter.Moves {
+        prompt += fmt.Sprintf("  move %d: %s (%s, %s, power %d, accuracy %d)\n", i+1, m.Name, m.Type, m.Category, m.Power, m.Accuracy)
+    }
+    for id, f := range g.fighterList {
+        if f != g.fighter && !f.Fainted() {
+            prompt += fmt.Sprintf("  switch %d: %s (HP %d/%d)\n", id, f.Pokemon.Name, f.HP, f.Stats.HP)
+        }
+    }
+    for _, key := range bagItems(g) {
+        prompt += fmt.Sprintf("  item %s: x%d\n", key, g.bag[key])
+    }
+    prompt += "  forfeit\n"
+    prompt += "Enter: " + battle.ActionHelp
+    return prompt
+}
 
-        switchTurn(attacker, defender)
+// List the items of a player's bag that can be used in battle, by name
+func bagItems(g *gamer) []string {
+    var keys []string
+    for key, count := range g.bag {
+        if item, ok := battle.LookupItem(key); ok && !item.Held && count > 0 {
+            keys = append(keys, key)
+        }
     }
-    sendMessage(serverConn, attacker.addr, "BATTLE ENDED!")
+    sort.Strings(keys)
+    return keys
 }
 
+// Parse a player's action and check it can be taken
+func checkAction(g *gamer, message string) (battle.Action, error) {
+    action, err := battle.ParseAction(message)
+    if err != nil {
+        return action, err
+    }
+
+    switch action.Kind {
+    case battle.ActionMove:
+        if action.Move < 0 || action.Move >= len(g.fighter.Moves) {
+            return action, fmt.Errorf("choose a move between 1 and %d", len(g.fighter.Moves))
+        }
+    case battle.ActionSwitch:
+        f, ok := g.fighterList[action.Switch]
+        switch {
+        case !ok:
+            return action, fmt.Errorf("no pokemon with ID %d in your team", action.Switch)
+        case f == g.fighter:
+            return action, fmt.Errorf("%s is already fighting", f.Pokemon.Name)
+        case f.Fainted():
+            return action, fmt.Errorf("%s has fainted", f.Pokemon.Name)
+        }
+    case battle.ActionItem:
+        item, ok := battle.LookupItem(action.Item)
+        if !ok {
+            return action, fmt.Errorf("unknown item %q", action.Item)
+        }
+        if g.bag[battle.ItemKey(action.Item)] <= 0 {
+            return action, fmt.Errorf("you have no %s left", item.Name)
+        }
+        target, ok := itemTarget(g, action)
+        if !ok {
+            return action, fmt.Errorf("no pokemon with ID %d in your team", action.Target)
+        }
+        if err := battle.CheckItem(item, target, target == g.fighter); err != nil {
+            return action, err
+        }
+    }
+    return action, nil
+}
+
+// Return the fighter an item is used on, the active one by default
+func itemTarget(g *gamer, action battle.Action) (*battle.Fighter, bool) {
+    if action.Target == 0 {
+        return g.fighter, true
+    }
+    f, ok := g.fighterList[action.Target]
+    return f, ok
+}
+
+// Carry out a player's action, reporting whether they forfeited
+func takeAction(g, foe *gamer, action battle.Action, rng *rand.Rand) bool {
+    switch action.Kind {
+    case battle.ActionForfeit:
+        sendBoth(g, foe, fmt.Sprintf("%s forfeited the battle!", g.name))
+        return true
+    case battle.ActionSwitch:
+        sendBoth(g, foe, fmt.Sprintf("%s withdrew %s and sent out %s!", g.name, g.fighter.Pokemon.Name, g.fighterList[action.Switch].Pokemon.Name))
+        g.fighter.SwitchOut()
+        g.fighter = g.fighterList[action.Switch]
+        battle.Face(g.fighter, foe.fighter)
+    case battle.ActionItem:
+        item, _ := battle.LookupItem(action.Item)
+        target, _ := itemTarget(g, action)
+        sendBoth(g, foe, fmt.Sprintf("%s used a %s on %s!", g.name, item.Name, target.Pokemon.Name))
+        if err := battle.CheckItem(item, target, target == g.fighter); err != nil {
+            sendBoth(g, foe, "But it had no effect!")
+            return false
+        }
+        g.bag[battle.ItemKey(action.Item)]--
+        for _, message := range battle.UseItem(item, target) {
+            sendBoth(g, foe, message)
+        }
+    case battle.ActionMove:
+        // A fighter knocked out earlier in the turn doesn't get to move
+        if g.fighter.Fainted() {
+            return false
+        }
+        // Sleep, freeze and paralysis can keep it from moving
+        canMove, message := battle.CanMove(g.fighter, rng)
+        if message != "" {
+            sendBoth(g, foe, message)
+        }
+        if !canMove {
+            return false
+        }
+        attack(g, foe, g.fighter.Moves[action.Move], rng)
+    }
+    return false
+}
+
+// Give the experience of loser's fainted fighter to the fighters of winner
+// that faced it, levelling them up as far as their growth rate allows
 func distributedExperiencePoints(winner, loser *gamer, conn *net.UDPConn) {
-    totalExp := 0
+    fainted := loser.fighter
+    shares := battle.ExpShares(fainted)
+    if len(shares) == 0 {
+        return
+    }
+
+    exp := battle.ExpYield(fainted, len(shares))
+    for _, f := range shares {
+        level := f.Pokemon.Level
+        gained := battle.GainExp(f, exp)
 
-    //Total EXP from loser's team
-    for _, pokemon := range loser.fighterList {
-        totalExp += pokemon.CurrentExp
+        message := fmt.Sprintf("%s's %s gained %d EXP. Points!", winner.name, f.Pokemon.Name, exp)
+        if gained > 0 {
+            message += fmt.Sprintf("\n%s grew from level %d to level %d!", f.Pokemon.Name, level, f.Pokemon.Level)
+        }
+        sendMessage(conn, winner.addr, message)
+        sendMessage(conn, loser.addr, message)
     }
+}
+
+// Save the outcome of a battle to a player's record: the experience,
+// levels and evolutions of their fighters, the items left in their bag and
+// on their Pokemon, and the battle itself in their history
+func recordBattle(g, foe *gamer, record player.BattleRecord) {
+    var messages []string
+    err := players.Update(g.name, func(p *player.Player) {
+        for i := range p.Pokemons {
+            pokemon := &p.Pokemons[i]
+            f, ok := g.fighterList[pokemon.ID]
+            if !ok {
+                continue
+            }
 
-    //EXP for each pokemon
-    expPerPokemon := totalExp / (3 * len(loser.fighterList))
-    sendMessage(conn, winner.addr, fmt.Sprintf("RECEIVE EXP: Each pokemon of %s will get %d bonus exp!", winner.name, expPerPokemon))
+            levelled := f.Pokemon.Level > pokemon.Level
+            pokemon.Level = f.Pokemon.Level
+            pokemon.CurrentExp = f.Pokemon.CurrentExp
+            pokemon.GrowthRate = f.Pokemon.GrowthRate
+            pokemon.HeldItem = f.Pokemon.HeldItem
+            if levelled {
+                if message := evolve(pokemon); message != "" {
+                    messages = append(messages, message)
+                }
+            }
+        }
 
-    //Distribute EXP for each pokemon of winner's team
-    for _, pokemon := range winner.fighterList {
-        pokemon.CurrentExp += expPerPokemon
-        //Check if pokemon have enough EXP to level up
-        if pokemon.CurrentExp > pokemon.BaseExp {
-            pokemon.CurrentExp = pokemon.BaseExp
+        p.Bag = make(map[string]int)
+        for name, count := range g.bag {
+            if count > 0 {
+                p.Bag[name] = count
+            }
         }
+        p.AddBattle(record)
+    })
+    if err != nil {
+        fmt.Println("Error saving player data:", err)
+        return
+    }
+
+    for _, message := range messages {
+        sendMessage(serverConn, g.addr, message)
+        sendMessage(serverConn, foe.addr, message)
     }
 }
 
-func attack(attacker, defender *gamer) {
-    // Determine if it's a special attack or not
-    r := rand.New(rand.NewSource(time.Now().UnixNano()))
-    isSpecialAttack := r.Intn(2) == 0
+// Evolve a Pokemon that levelled up as far as its level allows, and describe
+// its evolution. Without a pokedex no Pokemon evolves.
+func evolve(pokemon *player.CapturedPokemon) string {
+    if pokedex == nil {
+        return ""
+    }
+
+    from := pokemon.Name
+    for {
+        species, ok := pokedex.ByName(pokemon.Name)
+        if !ok {
+            break
+        }
+        evo, ok := species.EvolutionAt(pokemon.Level)
+        if !ok {
+            break
+        }
+        next, ok := pokedex.ByID(evo.ToID)
+        if !ok || next.Name == pokemon.Name {
+            break
+        }
+        pokemon.Evolve(next)
+    }
+
+    if pokemon.Name == from {
+        return ""
+    }
+    return fmt.Sprintf("What? %s is evolving!\nCongratulations! Your %s evolved into %s!", from, from, pokemon.Name)
+}
 
+func attack(attacker, defender *gamer, move dex.Move, r *rand.Rand) {
     // Print attacking and defending pokemon information
     sendMessage(serverConn, attacker.addr, "ATTACKING: ")
-    sendMessage(serverConn, attacker.addr, showPokemonProfile(attacker.fighter))
+    sendMessage(serverConn, attacker.addr, showFighterProfile(attacker.fighter))
     sendMessage(serverConn, defender.addr, "ATTACKING: ")
-    sendMessage(serverConn, defender.addr, showPokemonProfile(attacker.fighter))
+    sendMessage(serverConn, defender.addr, showFighterProfile(attacker.fighter))
     wait(2)
 
     sendMessage(serverConn, defender.addr, "DEFENDING: ")
-    sendMessage(serverConn, defender.addr, showPokemonProfile(defender.fighter))
+    sendMessage(serverConn, defender.addr, showFighterProfile(defender.fighter))
     sendMessage(serverConn, attacker.addr, "DEFENDING: ")
-    sendMessage(serverConn, attacker.addr, showPokemonProfile(defender.fighter))
+    sendMessage(serverConn, attacker.addr, showFighterProfile(defender.fighter))
     wait(2)
 
-    var damage int
-    if isSpecialAttack {
-        damage = int(float64(attacker.fighter.SpecialAtk) - float64(defender.fighter.SpecialDef))
-    } else {
-        damage = attacker.fighter.Attack - defender.fighter.Defense
-    }
-
-    // Ensure damage is at least 1
-    if damage < 1 {
-        damage = 1
-    }
-
-    // Apply damage to defender's HP
-    defender.fighter.HP -= damage
-
-    // Update defender's fighterList entry with new HP
-    defender.fighterList[defender.fighter.ID] = defender.fighter
+    // Roll accuracy, critical hit and damage, and apply it to defender's HP
+    hit := battle.Attack(attacker.fighter, defender.fighter, move, r)
 
     // Inform players about the attack and damage dealt
-    if isSpecialAttack {
-        sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s used a special attack!\n", attacker.fighter.Name))
-        sendMessage(serverConn, defender.addr, fmt.Sprintf("%s used a special attack!\n", attacker.fighter.Name))
+    sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s used %s!\n", attacker.fighter.Pokemon.Name, move.Name))
+    sendMessage(serverConn, defender.addr, fmt.Sprintf("%s used %s!\n", attacker.fighter.Pokemon.Name, move.Name))
+    if hit.Missed {
+        sendMessage(serverConn, attacker.addr, "The attack missed!")
+        sendMessage(serverConn, defender.addr, "The attack missed!")
+    } else if hit.Failed {
+        sendMessage(serverConn, attacker.addr, "But it failed!")
+        sendMessage(serverConn, defender.addr, "But it failed!")
     } else {
-        sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s used a normal attack!\n", attacker.fighter.Name))
-        sendMessage(serverConn, defender.addr, fmt.Sprintf("%s used a normal attack!\n", attacker.fighter.Name))
+        if hit.Critical {
+            sendMessage(serverConn, attacker.addr, "A critical hit!")
+            sendMessage(serverConn, defender.addr, "A critical hit!")
+        }
+        // Only an immunity is reported for status and fixed-damage moves
+        if message := battle.EffectivenessMessage(hit.Effectiveness, defender.fighter.Pokemon.Name); message != "" {
+            sendMessage(serverConn, attacker.addr, message)
+            sendMessage(serverConn, defender.addr, message)
+        }
+        if hit.Thawed {
+            sendMessage(serverConn, attacker.addr, defender.fighter.Pokemon.Name+" thawed out!")
+            sendMessage(serverConn, defender.addr, defender.fighter.Pokemon.Name+" thawed out!")
+        }
+        if hit.Status != "" {
+            message := battle.InflictedMessage(defender.fighter.Pokemon.Name, hit.Status)
+            sendMessage(serverConn, attacker.addr, message)
+            sendMessage(serverConn, defender.addr, message)
+        }
+        for _, change := range hit.StatChanges {
+            target := defender.fighter
+            if change.Self {
+                target = attacker.fighter
+            }
+            message := battle.StageMessage(target.Pokemon.Name, change)
+            sendMessage(serverConn, attacker.addr, message)
+            sendMessage(serverConn, defender.addr, message)
+        }
     }
 
     sendMessage(serverConn, attacker.addr, divider)
     sendMessage(serverConn, defender.addr, divider)
-    sendMessage(serverConn, attacker.addr, fmt.Sprintf("Damage dealt: %d\n", damage))
-    sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s's HP: %d\n", defender.fighter.Name, defender.fighter.HP))
-    sendMessage(serverConn, defender.addr, fmt.Sprintf("Damage dealt: %d\n", damage))
-    sendMessage(serverConn, defender.addr, fmt.Sprintf("%s's HP: %d\n", defender.fighter.Name, defender.fighter.HP))
-
-    wait(3)
-    // Prompt the attacker to switch their fighter
-    sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s, do you want to switch your fighter? (Y/N)", attacker.name))
-    response := receiveMessage(serverConn)
-    if strings.TrimSpace(strings.ToUpper(response)) == "Y" {
-        if selectFighter(attacker, serverConn) {
-            sendMessage(serverConn, attacker.addr, "You have switched your fighter.")
-        } else {
-            sendMessage(serverConn, attacker.addr, "Failed to switch fighter. Continue with the current fighter.")
-        }
-    }
+    sendMessage(serverConn, attacker.addr, fmt.Sprintf("Damage dealt: %d\n", hit.Damage))
+    sendMessage(serverConn, attacker.addr, fmt.Sprintf("%s's HP: %d\n", defender.fighter.Pokemon.Name, defender.fighter.HP))
+    sendMessage(serverConn, defender.addr, fmt.Sprintf("Damage dealt: %d\n", hit.Damage))
+    sendMessage(serverConn, defender.addr, fmt.Sprintf("%s's HP: %d\n", defender.fighter.Pokemon.Name, defender.fighter.HP))
+
+    wait(1)
 }
 
 func handleLogin(conn *net.UDPConn, addr *net.UDPAddr, message string) {
-    mutex.Lock()
-    defer mutex.Unlock()
-
     message = strings.TrimSpace(message) // Trim any leading/trailing whitespace
     username := strings.Split(message, ":")[1]
-    if _, exists := players[username]; exists {
+    if _, exists := players.Get(username); exists {
+        // A player can only be logged in from one client at a time
+        s, ok := clients.open(username, addr)
+        if !ok {
+            errorMessage := "FAILED: " + username + " is already logged in"
+            sendMessage(conn, addr, errorMessage)
+            fmt.Println("Error message sent to", addr, ":", errorMessage)
+            return
+        }
+
         // Username already exists, notify the client
         successMessage := "SUCCESS: You have registered as " + username
         sendMessage(conn, addr, successMessage)
         fmt.Println("Success message sent to", addr, ":", successMessage)
         sendMessage(conn, addr, "Welcome to the Pokemon Battle Server!\n")
-        go handleClient(conn, addr, username)
+        go handleClient(conn, s)
     } else {
         // Notify the client that no player found with this name
         errorMessage := "FAILED: no player found " + username
@@ -384,35 +715,88 @@ func handleLogin(conn *net.UDPConn, addr *net.UDPAddr, message string) {
     }
 }
 
-func handleLogout(message string) {
-    mutex.Lock()
-    defer mutex.Unlock()
-
+func handleLogout(conn *net.UDPConn, addr *net.UDPAddr, message string) {
     message = strings.TrimSpace(message) // Trim any leading/trailing whitespace
     username := strings.Split(message, ":")[1]
-    delete(players, username)
+    // Only the client a player logged in from can log them out
+    if !clients.close(username, addr) {
+        sendMessage(conn, addr, "ERROR: You are not logged in as "+username)
+        fmt.Println("Ignored logout of", username, "from", addr)
+        return
+    }
+    // The player's record stays saved, they only leave the lobby or forfeit
+    // their battle
+    rooms.leave(username)
+    sendMessage(conn, addr, "LOGGED OUT: See you next time, "+username+"!")
     fmt.Println("Client", username, "logged out")
 }
 
-func loadPlayerData() {
-    file, err := os.Open("../../player.json")
+func loadPlayerData(file string) {
+    store, err := player.LoadStore(file)
     if err != nil {
-        fmt.Println("Error opening player data file:", err)
+        fmt.Println("Error loading player data:", err)
         return
     }
-    defer file.Close()
+    players = store
 
-    var playersData []player.Player
+    fmt.Printf("Loaded %d players\n", players.Len())
+}
 
-    if err := json.NewDecoder(file).Decode(&playersData); err != nil {
-        fmt.Println("Error decoding player data:", err)
-        return
+// Load the species and move data used to give fighters their moves. Without
+// them every fighter falls back to basic attacks of its own types.
+func loadBattleData(pokedexFile, movesFile string) {
+    var err error
+    pokedex, err = dex.Load(pokedexFile)
+    if err != nil {
+        fmt.Println("Error loading pokedex:", err)
     }
-    for _, pd := range playersData {
-        players[pd.Name] = pd
+    movedex, err = dex.LoadMoves(movesFile)
+    if err != nil {
+        fmt.Println("Error loading moves:", err)
     }
+    if pokedex != nil && movedex != nil {
+        fmt.Printf("Loaded %d species and %d moves\n", pokedex.Len(), len(movedex))
+    }
+}
 
-    fmt.Printf("Loaded %d players\n", len(players))
+// The moves a pokemon knows at its level, or basic physical and special
+// attacks of its types when its level-up moves are unknown
+func fighterMoves(p player.CapturedPokemon) []dex.Move {
+    var moves []dex.Move
+    if pokedex != nil {
+        if species, ok := pokedex.ByName(p.Name); ok {
+            for _, name := range species.MovesAt(p.Level) {
+                if m, ok := movedex[strings.ToLower(name)]; ok {
+                    moves = append(moves, m)
+                }
+            }
+        }
+    }
+    if len(moves) > 0 {
+        return moves
+    }
+
+    types := p.Type
+    if len(types) == 0 {
+        types = []string{"Normal"}
+    }
+    for _, t := range types {
+        moves = append(moves, battle.BasicAttack(t, false), battle.BasicAttack(t, true))
+    }
+    return moves
+}
+
+// The growth rate of a pokemon's species, or "" when it is unknown, for
+// records captured before growth rates were scraped
+func growthRate(p player.CapturedPokemon) string {
+    if pokedex == nil {
+        return ""
+    }
+    species, ok := pokedex.ByName(p.Name)
+    if !ok {
+        return ""
+    }
+    return species.GrowthRate
 }
 
 func handleMessage(msg string, senderAddr *net.UDPAddr) {
@@ -425,7 +809,15 @@ func handleMessage(msg string, senderAddr *net.UDPAddr) {
 }
 
 func main() {
-    loadPlayerData()
+    flag.Int64Var(&seed, "seed", 0, "seed the random rolls of every battle with this value, to replay a logged battle (0 for a random seed)")
+    pokedexFile := flag.String("pokedex", "../../../POKEMON-GAME-POKEDEX/pokedex.json", "dex file giving each species its level-up moves")
+    movesFile := flag.String("moves", "../../../POKEMON-GAME-POKEDEX/moves.json", "move database written by the Pokedex scraper")
+    playersFile := flag.String("players", "../../player.json", "player file the battle results are saved to")
+    flag.DurationVar(&turnTimeout, "turn-timeout", time.Minute, "time players have to choose their action each turn")
+    flag.Parse()
+
+    loadPlayerData(*playersFile)
+    loadBattleData(*pokedexFile, *movesFile)
     //UDP address
     serverAddr, err := net.ResolveUDPAddr("udp", ":8080")
     if err != nil {
@@ -440,42 +832,18 @@ func main() {
 
     fmt.Println("Server started, waiting for player...")
 
-    //Buffer for incoming messages
-    buf := make([]byte, 1024)
+    // The dispatcher is the only reader of the socket: it hands players'
+    // replies to their sessions and everything else to this loop
+    clients = newDispatcher(serverConn)
+    go clients.run()
 
-    for {
-        n, addr, _ := serverConn.ReadFromUDP(buf)
-        message := string(buf[:n])
-        fmt.Println("Received message from client: ", message)
-
-        if strings.HasPrefix(message, "LOGIN:") {
-            handleLogin(serverConn, addr, message)
-        } else if strings.HasPrefix(message, "LOGOUT:") {
-            handleLogout(message)
+    for d := range clients.control {
+        if strings.HasPrefix(d.message, "LOGIN:") {
+            handleLogin(serverConn, d.addr, d.message)
+        } else if strings.HasPrefix(d.message, "LOGOUT:") {
+            handleLogout(serverConn, d.addr, d.message)
         } else {
-            mutex.Lock()
-            if len(gamers) == 2 && !battleActive {
-                battleActive = true
-                var gamer1, gamer2 *gamer
-                for _, g := range gamers {
-                    if gamer1 == nil {
-                        gamer1 = g
-                    } else {
-                        gamer2 = g
-                    }
-                }
-                mutex.Unlock()
-
-                go startBattle(gamer1, gamer2)
-
-                mutex.Lock()
-                gamers = make(map[string]*gamer)
-                battleActive = false
-                mutex.Unlock()
-            } else {
-                mutex.Unlock()
-            }
-            go handleMessage(message, addr)
+            go handleMessage(d.message, d.addr)
         }
     }
 }
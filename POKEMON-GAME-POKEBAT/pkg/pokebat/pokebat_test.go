@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"POKEMON-GAME-POKEBAT/pkg/player"
+)
+
+func TestParseTeam(t *testing.T) {
+	pokemons := []player.CapturedPokemon{
+		{ID: 1, Name: "Pikachu"},
+		{ID: 2, Name: "Pidgeot"},
+		{ID: 5, Name: "Onix"},
+		{ID: 7, Name: "Gengar"},
+	}
+
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{input: "1 2 5", want: []string{"Pikachu", "Pidgeot", "Onix"}},
+		{input: " 7  1 2 ", want: []string{"Gengar", "Pikachu", "Pidgeot"}},
+		{input: "1 1 1", wantErr: true},
+		{input: "1 2 2", wantErr: true},
+		{input: "1 2 3", wantErr: true},
+		{input: "1 2", wantErr: true},
+		{input: "1 2 5 7", wantErr: true},
+		{input: "1 two 5", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		team, err := parseTeam(tt.input, pokemons, 3)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTeam(%q) error = %v, want error %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, p := range team {
+			got = append(got, p.Name)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseTeam(%q) = %v, want %v", tt.input, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("parseTeam(%q) = %v, want %v", tt.input, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// room is one battle between two players. Each room runs in its own
+// goroutine with its own state, reading its players' replies from their
+// sessions, so any number of battles can run at once.
+type room struct {
+	id     int
+	gamer1 *gamer
+	gamer2 *gamer
+}
+
+// roomManager pairs players as they finish choosing their team and keeps
+// track of the running rooms by player name.
+type roomManager struct {
+	mu      sync.Mutex
+	nextID  int
+	waiting *gamer
+	byName  map[string]*room
+}
+
+var rooms = &roomManager{byName: make(map[string]*room)}
+
+// join puts a player with their team in the lobby. The first player waits
+// for an opponent; the second one starts a battle room with them.
+func (m *roomManager) join(g *gamer) {
+	m.mu.Lock()
+	if m.waiting == nil || m.waiting.session.closed() {
+		m.waiting = g
+		m.mu.Unlock()
+		sendMessage(serverConn, g.addr, "Waiting for an opponent...")
+		return
+	}
+
+	m.nextID++
+	r := &room{id: m.nextID, gamer1: m.waiting, gamer2: g}
+	m.waiting = nil
+	m.byName[r.gamer1.name] = r
+	m.byName[r.gamer2.name] = r
+	m.mu.Unlock()
+
+	fmt.Printf("Room %d: %s vs %s\n", r.id, r.gamer1.name, r.gamer2.name)
+	go func() {
+		startBattle(r)
+		m.close(r)
+	}()
+}
+
+// leave takes a logged out player out of the lobby. A player logging out
+// of a battle forfeits it once the room sees their session closed.
+func (m *roomManager) leave(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.waiting != nil && m.waiting.name == name {
+		m.waiting = nil
+	}
+}
+
+// close forgets a room whose battle has ended and logs its players out,
+// so they can log in again for another battle.
+func (m *roomManager) close(r *room) {
+	m.mu.Lock()
+	for _, g := range []*gamer{r.gamer1, r.gamer2} {
+		delete(m.byName, g.name)
+	}
+	m.mu.Unlock()
+
+	for _, g := range []*gamer{r.gamer1, r.gamer2} {
+		if !g.session.closed() {
+			sendMessage(serverConn, g.addr, "LOGGED OUT: The battle is over, log in again to play another one.")
+		}
+		clients.end(g.session)
+	}
+	fmt.Printf("Room %d closed\n", r.id)
+}
+
+// receive waits for the next reply of either player of the room until
+// deadline, reporting false if the deadline passes first. A player who
+// logged out is returned with an empty reply.
+func (r *room) receive(deadline time.Time) (*gamer, string, bool) {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	select {
+	case message := <-r.gamer1.session.inbox:
+		return r.gamer1, message, true
+	case message := <-r.gamer2.session.inbox:
+		return r.gamer2, message, true
+	case <-r.gamer1.session.done:
+		return r.gamer1, "", true
+	case <-r.gamer2.session.done:
+		return r.gamer2, "", true
+	case <-timer.C:
+		return nil, "", false
+	}
+}
@@ -3,6 +3,9 @@ package utils
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
+
+	"pokemon-game/dex"
 )
 
 func SaveToFile(filePath string, data interface{}) error {
@@ -10,7 +13,34 @@ func SaveToFile(filePath string, data interface{}) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(filePath, file, 0644)
+	return WriteFileAtomic(filePath, file)
+}
+
+// WriteFileAtomic replaces the file at filePath with data, writing it to a
+// temporary file in the same directory first so readers and crashes see
+// either the old or the new contents, never a partial file.
+func WriteFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
 }
 
 func LoadFromFile(filePath string, data interface{}) error {
@@ -21,3 +51,18 @@ func LoadFromFile(filePath string, data interface{}) error {
 
 	return json.Unmarshal(file, data)
 }
+
+// SaveVersioned writes list under key to a file of the current schema version.
+func SaveVersioned(filePath, key string, list interface{}) error {
+	file, err := dex.EncodeVersioned(key, list)
+	if err != nil {
+		return err
+	}
+	return WriteFileAtomic(filePath, file)
+}
+
+// LoadVersioned reads the list stored under key in a dex or player file,
+// upgrading older schema versions and rejecting unknown ones.
+func LoadVersioned(filePath, key string, list interface{}) error {
+	return dex.LoadVersioned(filePath, key, list)
+}
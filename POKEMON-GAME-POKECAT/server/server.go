@@ -2,14 +2,18 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
+
+	"pokemon-game/dex"
 )
 
 const (
@@ -25,38 +29,52 @@ type Coord struct {
 }
 
 type Player struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	PokemonList  []Pokemon `json:"pokemon_list"`
+	ID           int              `json:"id"`
+	Name         string           `json:"name"`
+	PokemonList  []SpawnedPokemon `json:"pokemon_list"`
 	CurrentCoord Coord
 	mu           sync.Mutex
 }
 
-type Pokemon struct {
-	ID         int      `json:"id"`
-	Name       string   `json:"name"`
-	Type       []string `json:"type"`
-	BaseExp    int      `json:"base_exp"`
-	Speed      int      `json:"speed"`
-	Attack     int      `json:"attack"`
-	Defense    int      `json:"defense"`
-	SpecialAtk int      `json:"special_atk"`
-	SpecialDef int      `json:"special_def"`
-	HP         int      `json:"hp"`
-	EV         float64  `json:"ev"`
-	CurrentExp int      `json:"current_exp"`
-	Level      int      `json:"level"`
+// SpawnedPokemon is one Pokemon spawned on the grid, with the species data
+// copied from the dex and the IVs and nature rolled for it. A player keeps
+// it as is once captured.
+type SpawnedPokemon struct {
+	ID         int          `json:"id"`
+	Name       string       `json:"name"`
+	Type       []string     `json:"type"`
+	BaseExp    int          `json:"base_exp"`
+	Speed      int          `json:"speed"`
+	Attack     int          `json:"attack"`
+	Defense    int          `json:"defense"`
+	SpecialAtk int          `json:"special_atk"`
+	SpecialDef int          `json:"special_def"`
+	HP         int          `json:"hp"`
+	EV         float64      `json:"ev"`
+	EVYield    *dex.EVYield `json:"ev_yield,omitempty"`
+	CatchRate  int          `json:"catch_rate,omitempty"`
+	GrowthRate string       `json:"growth_rate,omitempty"`
+	CurrentExp int          `json:"current_exp"`
+	Level      int          `json:"level"`
+	IVs        *dex.Stats   `json:"ivs,omitempty"`
+	Nature     string       `json:"nature,omitempty"`
 	SpawnTime  time.Time
 	Coord      Coord
 }
 
+// spawnedListKey holds the list in the spawned Pokemon file, which has the
+// same layout as the dex and player files
+const spawnedListKey = "spawned"
+
 var (
 	players          []Player
-	pokemons         []Pokemon
-	currentPokemon   []Pokemon
+	pokedex          *dex.Dex
+	spawned          []SpawnedPokemon
+	currentPokemon   []SpawnedPokemon
 	playerIDCounter  = 0
 	pokemonIDCounter = 0
 	pokemonFile      = "pokedex.json"
+	spawnedFile      = "spawned.json"
 	playerFile       = "player.json"
 )
 
@@ -181,6 +199,7 @@ func showPokemons(conn net.Conn, player *Player) {
 func loadGameData() {
 	loadPlayers()
 	loadPokemons()
+	loadSpawned()
 }
 
 // Load player data from file
@@ -190,7 +209,7 @@ func loadPlayers() {
 		fmt.Println("Error reading player file:", err.Error())
 		return
 	}
-	err = json.Unmarshal(data, &players)
+	err = dex.DecodeVersioned(data, dex.PlayerListKey, &players)
 	if err != nil {
 		fmt.Println("Error unmarshalling player data:", err.Error())
 		return
@@ -201,29 +220,49 @@ func loadPlayers() {
 	fmt.Println("Players loaded:", len(players))
 }
 
-// Load Pokemon data from file
+// Load the species the server spawns from the dex, which the server only
+// ever reads
 func loadPokemons() {
-	data, err := os.ReadFile(pokemonFile)
+	var err error
+	pokedex, err = dex.Load(pokemonFile)
 	if err != nil {
-		fmt.Println("Error reading pokemon file:", err.Error())
+		fmt.Println("Error loading pokemon file:", err.Error())
+		return
+	}
+	fmt.Println("Pokemons loaded:", pokedex.Len())
+}
+
+// Load the Pokemon spawned so far and carry on their IDs, and those of
+// the Pokemon players captured, so no two Pokemon share one
+func loadSpawned() {
+	for i := range players {
+		for _, pokemon := range players[i].PokemonList {
+			pokemonIDCounter = max(pokemonIDCounter, pokemon.ID)
+		}
+	}
+
+	err := dex.LoadVersioned(spawnedFile, spawnedListKey, &spawned)
+	if errors.Is(err, os.ErrNotExist) {
 		return
 	}
-	err = json.Unmarshal(data, &pokemons)
 	if err != nil {
-		fmt.Println("Error unmarshalling pokemon data:", err.Error())
+		fmt.Println("Error loading spawned pokemon file:", err.Error())
 		return
 	}
-	fmt.Println("Pokemons loaded:", len(pokemons))
+	for _, pokemon := range spawned {
+		pokemonIDCounter = max(pokemonIDCounter, pokemon.ID)
+	}
+	fmt.Println("Spawned pokemons loaded:", len(spawned))
 }
 
 // Save player data to file
 func savePlayers() {
-	data, err := json.MarshalIndent(players, "", " ")
+	data, err := dex.EncodeVersioned(dex.PlayerListKey, players)
 	if err != nil {
 		fmt.Println("Error marshalling players data:", err.Error())
 		return
 	}
-	err = os.WriteFile(playerFile, data, 0644)
+	err = writeFileAtomic(playerFile, data)
 	if err != nil {
 		fmt.Println("Error writing player file:", err.Error())
 		return
@@ -231,19 +270,45 @@ func savePlayers() {
 	fmt.Println("Players saved:", len(players))
 }
 
-// Save Pokemon data to file
-func savePokemons() {
-	data, err := json.MarshalIndent(pokemons, "", " ")
+// Save the Pokemon spawned so far to their own file
+func saveSpawned() {
+	data, err := dex.EncodeVersioned(spawnedListKey, spawned)
 	if err != nil {
-		fmt.Println("Error marshalling pokemons data:", err.Error())
+		fmt.Println("Error marshalling spawned pokemon data:", err.Error())
 		return
 	}
-	err = os.WriteFile(pokemonFile, data, 0644)
+	err = writeFileAtomic(spawnedFile, data)
 	if err != nil {
-		fmt.Println("Error writing pokemon file:", err.Error())
+		fmt.Println("Error writing spawned pokemon file:", err.Error())
 		return
 	}
-	fmt.Println("Pokemons saved:", len(pokemons))
+	fmt.Println("Spawned pokemons saved:", len(spawned))
+}
+
+// Write data to a temporary file next to filePath and rename it into place,
+// so a crash mid-write never leaves a truncated file behind
+func writeFileAtomic(filePath string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filePath)
 }
 
 // Generate a random coordinate within the grid
@@ -263,13 +328,12 @@ func pokemonSpawner() {
 func spawnPokemons() {
 	currentPokemon = nil
 	for i := 0; i < PokemonPerWave; i++ {
-		pokemonIDCounter++
 		pokemon := generateRandomPokemon()
-		pokemons = append(pokemons, pokemon)
+		spawned = append(spawned, pokemon)
 		fmt.Println("Spawned pokemon:", pokemon.Name, "at", pokemon.Coord.X, pokemon.Coord.Y)
 		currentPokemon = append(currentPokemon, pokemon)
 	}
-	savePokemons()
+	saveSpawned()
 }
 
 // Initialize a new player
@@ -278,7 +342,7 @@ func initializePlayer(name string) *Player {
 	player := &Player{
 		ID:           playerIDCounter,
 		Name:         name,
-		PokemonList:  []Pokemon{},
+		PokemonList:  []SpawnedPokemon{},
 		CurrentCoord: getRandomCoord(),
 	}
 	players = append(players, *player)
@@ -314,13 +378,23 @@ func movePlayer(player *Player, newX, newY int) {
 }
 
 // Generate a random pokemon based on loaded pokemon data
-func generateRandomPokemon() Pokemon {
+func generateRandomPokemon() SpawnedPokemon {
 	randCoord := getRandomCoord()
-	pokemonIndex := rand.Intn(len(pokemons))
-	pokemonData := pokemons[pokemonIndex]
+	pokemonIndex := rand.Intn(pokedex.Len())
+	pokemonData := pokedex.Pokemons[pokemonIndex]
+
+	// Species scraped before per-stat yields only carry the total in EV
+	var evYield *dex.EVYield
+	if pokemonData.EVYield.Total() > 0 {
+		evYield = &pokemonData.EVYield
+	}
+
+	// Roll the individual values and nature this pokemon keeps once captured
+	r := rand.New(rand.NewSource(rand.Int63()))
+	ivs := dex.RandomIVs(r)
 
 	pokemonIDCounter++
-	return Pokemon{
+	return SpawnedPokemon{
 		ID:         pokemonIDCounter,
 		Name:       pokemonData.Name,
 		Type:       pokemonData.Type,
@@ -332,8 +406,13 @@ func generateRandomPokemon() Pokemon {
 		SpecialDef: pokemonData.SpecialDef,
 		HP:         pokemonData.HP,
 		EV:         pokemonData.EV,
-		CurrentExp: pokemonData.BaseExp,
+		EVYield:    evYield,
+		CatchRate:  pokemonData.CatchRate,
+		GrowthRate: pokemonData.GrowthRate,
+		CurrentExp: dex.ExpForLevel(pokemonData.GrowthRate, 1),
 		Level:      1,
+		IVs:        &ivs,
+		Nature:     dex.RandomNature(r).Name,
 		SpawnTime:  time.Now(),
 		Coord:      randCoord,
 	}
@@ -349,6 +428,12 @@ func capturePokemon(player *Player) {
 			fmt.Println("Pokemon address:", pokemon.Coord.X, pokemon.Coord.Y)
 			fmt.Println("Player address:", player.CurrentCoord.X, player.CurrentCoord.Y)
 
+			// Roll against the species catch rate
+			if rand.Float64() >= captureChance(pokemon.CatchRate) {
+				fmt.Println(pokemon.Name, "broke free from", player.Name)
+				return
+			}
+
 			fmt.Println("Player", player.Name, "captured", pokemon.Name)
 
 			// Add the captured Pokémon to the player's list
@@ -363,8 +448,7 @@ func capturePokemon(player *Player) {
 				}
 			}
 
-			// Save the changes to the Pokémon and players
-			savePokemons()
+			// Save the changes to the players
 			savePlayers()
 			return
 		}
@@ -372,3 +456,25 @@ func capturePokemon(player *Player) {
 
 	fmt.Println("No Pokemon to capture at", player.CurrentCoord.X, player.CurrentCoord.Y)
 }
+
+// Chance of capturing a wild pokemon at full HP with a Poke Ball, using the
+// generation III-IV formula: the ball shakes four times, each shake passing
+// with probability b/65536. A catch rate of 0 means the dex predates catch
+// rates, in which case every capture succeeds as before.
+func captureChance(catchRate int) float64 {
+	if catchRate <= 0 {
+		return 1
+	}
+
+	// Modified catch rate at full HP: (3*maxHP - 2*HP) * rate / (3*maxHP)
+	a := float64(catchRate) / 3
+	if a >= 255 {
+		return 1
+	}
+	if a < 1 {
+		a = 1
+	}
+
+	b := 1048560 / math.Sqrt(math.Sqrt(16711680/a))
+	return math.Pow(b/65536, 4)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheMeta holds the validators of a cached page, stored next to it.
+type cacheMeta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// PageCache stores fetched pages on disk using the DirSource layout, so a
+// cache directory can also be replayed with -offline. Each page has a
+// .meta.json sidecar with its ETag and Last-Modified validators.
+type PageCache struct {
+	Dir string
+}
+
+// Load returns the cached page at path and its metadata.
+func (c *PageCache) Load(path string) ([]byte, cacheMeta, bool) {
+	var meta cacheMeta
+	file := pageFile(c.Dir, path)
+
+	metaData, err := os.ReadFile(file + ".meta.json")
+	if err != nil {
+		return nil, meta, false
+	}
+	if err := json.Unmarshal(metaData, &meta); err != nil {
+		return nil, meta, false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, meta, false
+	}
+	return data, meta, true
+}
+
+// Store saves page data and metadata for path. Both files are written
+// atomically so an interrupted run never leaves a truncated page behind.
+func (c *PageCache) Store(path string, data []byte, meta cacheMeta) error {
+	file := pageFile(c.Dir, path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	metaData, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(file, data); err != nil {
+		return err
+	}
+	return writeFileAtomic(file+".meta.json", metaData)
+}
+
+// CacheSource serves pages from Cache while they are younger than MaxAge
+// and falls back to Source otherwise. Source is expected to be an
+// HTTPSource sharing the same cache, which revalidates stale pages with
+// their ETag/Last-Modified and stores what it fetches.
+type CacheSource struct {
+	Source PageSource
+	Cache  *PageCache
+	MaxAge time.Duration
+}
+
+func (s *CacheSource) Fetch(path string) (io.ReadCloser, error) {
+	data, meta, ok := s.Cache.Load(path)
+	if ok && time.Since(meta.FetchedAt) < s.MaxAge {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	return s.Source.Fetch(path)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over file.
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
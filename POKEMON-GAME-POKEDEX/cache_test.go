@@ -0,0 +1,106 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingSource serves pages from Source and records the paths fetched.
+type countingSource struct {
+	Source PageSource
+
+	mu      sync.Mutex
+	fetched []string
+}
+
+func (s *countingSource) Fetch(path string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	s.fetched = append(s.fetched, path)
+	s.mu.Unlock()
+	return s.Source.Fetch(path)
+}
+
+func TestPageCache(t *testing.T) {
+	cache := &PageCache{Dir: t.TempDir()}
+	if _, _, ok := cache.Load("/pokedex/bulbasaur"); ok {
+		t.Fatal("Load from an empty cache succeeded")
+	}
+
+	meta := cacheMeta{
+		URL:          "https://pokemondb.net/pokedex/bulbasaur",
+		ETag:         `"abc"`,
+		LastModified: "Mon, 02 Jan 2006 15:04:05 GMT",
+		FetchedAt:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := cache.Store("/pokedex/bulbasaur", []byte("<html>Bulbasaur</html>"), meta); err != nil {
+		t.Fatalf("Store: %v", err)
+	}
+
+	data, got, ok := cache.Load("/pokedex/bulbasaur")
+	if !ok || string(data) != "<html>Bulbasaur</html>" || got != meta {
+		t.Errorf("Load = %q, %+v, %v, want the stored page and %+v", data, got, ok, meta)
+	}
+
+	// The cache uses the layout DirSource reads, leaving no temporary
+	// files behind
+	page, err := (&DirSource{Dir: cache.Dir}).Fetch("/pokedex/bulbasaur")
+	if err != nil {
+		t.Fatalf("DirSource on the cache: %v", err)
+	}
+	page.Close()
+	entries, _ := os.ReadDir(filepath.Join(cache.Dir, "pokedex"))
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s left in the cache", e.Name())
+		}
+	}
+
+	// A page whose metadata is missing counts as not cached
+	os.Remove(filepath.Join(cache.Dir, "pokedex", "bulbasaur.html.meta.json"))
+	if _, _, ok := cache.Load("/pokedex/bulbasaur"); ok {
+		t.Error("Load without metadata succeeded")
+	}
+}
+
+func TestCacheSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		age     time.Duration
+		cached  bool
+		want    string
+		fetched int
+	}{
+		{name: "fresh", age: time.Minute, cached: true, want: "cached", fetched: 0},
+		{name: "stale", age: 2 * time.Hour, cached: true, want: "live", fetched: 1},
+		{name: "missing", want: "live", fetched: 1},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		liveDir := filepath.Join(dir, "live")
+		os.MkdirAll(filepath.Join(liveDir, "pokedex"), 0755)
+		os.WriteFile(filepath.Join(liveDir, "pokedex", "eevee.html"), []byte("live"), 0644)
+
+		cache := &PageCache{Dir: filepath.Join(dir, "cache")}
+		if tt.cached {
+			cache.Store("/pokedex/eevee", []byte("cached"), cacheMeta{FetchedAt: time.Now().Add(-tt.age)})
+		}
+		live := &countingSource{Source: &DirSource{Dir: liveDir}}
+		src := &CacheSource{Source: live, Cache: cache, MaxAge: time.Hour}
+
+		body, err := src.Fetch("/pokedex/eevee")
+		if err != nil {
+			t.Fatalf("%s: Fetch: %v", tt.name, err)
+		}
+		data, _ := io.ReadAll(body)
+		body.Close()
+		if string(data) != tt.want || len(live.fetched) != tt.fetched {
+			t.Errorf("%s: Fetch = %q with %d live fetches, want %q with %d", tt.name, data, len(live.fetched), tt.want, tt.fetched)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pokemon-game/dex"
+)
+
+// checkpointEntry is one line of the checkpoint file.
+type checkpointEntry struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Pokemon   Pokemon   `json:"pokemon"`
+}
+
+// Checkpoint appends every scraped entry to a newline-delimited JSON file
+// as soon as it is fetched, so an interrupted run can resume without
+// refetching what it already has.
+type Checkpoint struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// OpenCheckpoint opens the checkpoint at path for appending and returns the
+// entries already recorded in it, keyed by dex ID.
+func OpenCheckpoint(path string) (*Checkpoint, map[int]checkpointEntry, error) {
+	entries, err := readCheckpoint(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	//Terminate a line cut short by an interruption before appending to it
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, info.Size()-1); err == nil && last[0] != '\n' {
+			file.Write([]byte{'\n'})
+		}
+	}
+	return &Checkpoint{path: path, file: file}, entries, nil
+}
+
+// readCheckpoint reads the entries recorded at path. A missing file yields
+// no entries, and a line cut short by an interruption is ignored.
+func readCheckpoint(path string) (map[int]checkpointEntry, error) {
+	entries := make(map[int]checkpointEntry)
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries[entry.Pokemon.ID] = entry
+	}
+	return entries, scanner.Err()
+}
+
+// Record appends pokemon to the checkpoint.
+func (c *Checkpoint) Record(pokemon Pokemon) error {
+	line, err := json.Marshal(checkpointEntry{FetchedAt: time.Now(), Pokemon: pokemon})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.file.Write(append(line, '\n'))
+	return err
+}
+
+// Remove closes and deletes the checkpoint once its entries are safely
+// merged into the output file.
+func (c *Checkpoint) Remove() error {
+	c.file.Close()
+	return os.Remove(c.path)
+}
+
+// fetchedFile returns the file next to the JSON dex recording when each of
+// its entries was fetched.
+func fetchedFile(outFile string) string {
+	return strings.TrimSuffix(outFile, filepath.Ext(outFile)) + ".fetched.json"
+}
+
+// fetchDates is the content of a fetched file.
+type fetchDates struct {
+	FetchedAt map[int]time.Time `json:"fetched_at"`
+}
+
+// loadFetchDates reads the fetch dates recorded next to outFile. A missing
+// file yields no dates.
+func loadFetchDates(outFile string) (map[int]time.Time, error) {
+	data, err := os.ReadFile(fetchedFile(outFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return map[int]time.Time{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var dates fetchDates
+	if err := json.Unmarshal(data, &dates); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", fetchedFile(outFile), err)
+	}
+	return dates.FetchedAt, nil
+}
+
+// saveFetchDates records when each entry of the dex at outFile was fetched.
+func saveFetchDates(outFile string, dates map[int]time.Time) error {
+	data, err := json.MarshalIndent(fetchDates{FetchedAt: dates}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(fetchedFile(outFile), data)
+}
+
+// loadPreviousEntries returns the entries of an existing output file, dated
+// by the fetch dates recorded next to it, overlaid with the checkpoint
+// entries. Entries without a recorded date, such as those of a dex written
+// before dates were kept, have a zero date and so are always stale.
+func loadPreviousEntries(outFile string, checkpoint map[int]checkpointEntry) (map[int]checkpointEntry, error) {
+	previous := make(map[int]checkpointEntry)
+
+	data, err := os.ReadFile(outFile)
+	if err == nil {
+		var pokemons []Pokemon
+		if err := dex.DecodeVersioned(data, dex.DexListKey, &pokemons); err != nil {
+			return nil, fmt.Errorf("reading %s: %v", outFile, err)
+		}
+		dates, err := loadFetchDates(outFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, pokemon := range pokemons {
+			previous[pokemon.ID] = checkpointEntry{FetchedAt: dates[pokemon.ID], Pokemon: pokemon}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for id, entry := range checkpoint {
+		previous[id] = entry
+	}
+	return previous, nil
+}
+
+// mergeEntries overlays fetched onto the previous entries and returns the
+// result sorted by dex ID.
+func mergeEntries(previous map[int]checkpointEntry, fetched []Pokemon) []Pokemon {
+	merged := make(map[int]Pokemon, len(previous)+len(fetched))
+	for id, entry := range previous {
+		merged[id] = entry.Pokemon
+	}
+	for _, pokemon := range fetched {
+		merged[pokemon.ID] = pokemon
+	}
+
+	pokemons := make([]Pokemon, 0, len(merged))
+	for _, pokemon := range merged {
+		pokemons = append(pokemons, pokemon)
+	}
+	sort.Slice(pokemons, func(i, j int) bool { return pokemons[i].ID < pokemons[j].ID })
+	return pokemons
+}
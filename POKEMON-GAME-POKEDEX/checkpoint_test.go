@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"pokemon-game/dex"
+)
+
+func TestCheckpointResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.checkpoint")
+
+	checkpoint, entries, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("new checkpoint has %d entries", len(entries))
+	}
+	for _, p := range []Pokemon{validEntry(1, "Bulbasaur"), validEntry(2, "Ivysaur")} {
+		if err := checkpoint.Record(p); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	checkpoint.file.Close()
+
+	// Simulate a run interrupted halfway through writing an entry
+	f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	f.WriteString(`{"fetched_at":"2024-05-01T12:00:00Z","pokemon":{"id":3,"na`)
+	f.Close()
+
+	checkpoint, entries, err = OpenCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint after interruption: %v", err)
+	}
+	if len(entries) != 2 || entries[1].Pokemon.Name != "Bulbasaur" || entries[2].Pokemon.Name != "Ivysaur" {
+		t.Errorf("resumed entries = %+v, want Bulbasaur and Ivysaur", entries)
+	}
+	if entries[1].FetchedAt.IsZero() {
+		t.Error("resumed entry has no fetch date")
+	}
+
+	// Entries recorded after resuming start on a line of their own
+	if err := checkpoint.Record(validEntry(3, "Venusaur")); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	entries, err = readCheckpoint(path)
+	if err != nil {
+		t.Fatalf("readCheckpoint: %v", err)
+	}
+	if len(entries) != 3 || entries[3].Pokemon.Name != "Venusaur" {
+		t.Errorf("entries after resuming = %+v, want three with Venusaur", entries)
+	}
+
+	if err := checkpoint.Remove(); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("checkpoint still exists after Remove: %v", err)
+	}
+}
+
+func TestLoadPreviousEntries(t *testing.T) {
+	dir := t.TempDir()
+	outFile := filepath.Join(dir, "pokedex.json")
+	fetched := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// Without an output file only the checkpoint counts
+	checkpoint := map[int]checkpointEntry{
+		2: {FetchedAt: fetched.Add(time.Hour), Pokemon: validEntry(2, "Ivysaur (checkpoint)")},
+	}
+	previous, err := loadPreviousEntries(outFile, checkpoint)
+	if err != nil {
+		t.Fatalf("loadPreviousEntries without output: %v", err)
+	}
+	if len(previous) != 1 {
+		t.Errorf("loadPreviousEntries without output = %d entries, want 1", len(previous))
+	}
+
+	data, _ := dex.EncodeVersioned(dex.DexListKey, []Pokemon{validEntry(1, "Bulbasaur"), validEntry(2, "Ivysaur"), validEntry(3, "Venusaur")})
+	os.WriteFile(outFile, data, 0644)
+	if err := saveFetchDates(outFile, map[int]time.Time{1: fetched, 2: fetched}); err != nil {
+		t.Fatalf("saveFetchDates: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pokedex.fetched.json")); err != nil {
+		t.Errorf("fetch dates not saved next to the dex: %v", err)
+	}
+
+	previous, err = loadPreviousEntries(outFile, checkpoint)
+	if err != nil {
+		t.Fatalf("loadPreviousEntries: %v", err)
+	}
+	tests := []struct {
+		id        int
+		name      string
+		fetchedAt time.Time
+	}{
+		{1, "Bulbasaur", fetched},
+		{2, "Ivysaur (checkpoint)", fetched.Add(time.Hour)},
+		// Entries without a recorded date are always stale
+		{3, "Venusaur", time.Time{}},
+	}
+	for _, tt := range tests {
+		entry := previous[tt.id]
+		if entry.Pokemon.Name != tt.name || !entry.FetchedAt.Equal(tt.fetchedAt) {
+			t.Errorf("entry %d = %s fetched %v, want %s fetched %v", tt.id, entry.Pokemon.Name, entry.FetchedAt, tt.name, tt.fetchedAt)
+		}
+	}
+
+	os.WriteFile(outFile, []byte("not json"), 0644)
+	if _, err := loadPreviousEntries(outFile, nil); err == nil {
+		t.Error("loadPreviousEntries of a corrupt dex succeeded")
+	}
+}
+
+func TestMergeEntries(t *testing.T) {
+	previous := map[int]checkpointEntry{
+		3: {Pokemon: validEntry(3, "Venusaur")},
+		1: {Pokemon: validEntry(1, "Bulbasaur (old)")},
+	}
+	merged := mergeEntries(previous, []Pokemon{validEntry(2, "Ivysaur"), validEntry(1, "Bulbasaur")})
+
+	var got []string
+	for _, p := range merged {
+		got = append(got, entryName(p))
+	}
+	want := []string{"#1 Bulbasaur", "#2 Ivysaur", "#3 Venusaur"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeEntries = %v, want %v", got, want)
+	}
+}
+
+func TestFetchDataReuse(t *testing.T) {
+	cached := validEntry(1, "Bulbasaur (cached)")
+	tests := []struct {
+		name      string
+		fetchedAt time.Time
+		reused    bool
+	}{
+		{"fresh", time.Now().Add(-time.Minute), true},
+		{"stale", time.Now().Add(-48 * time.Hour), false},
+		{"undated", time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		src := &countingSource{Source: fixtures}
+		opts := scrapeOptions{
+			Workers:  1,
+			Previous: map[int]checkpointEntry{1: {FetchedAt: tt.fetchedAt, Pokemon: cached}},
+			MaxAge:   24 * time.Hour,
+		}
+		var skipped SkipReport
+		pokemons, err := fetchData(src, opts, &skipped)
+		if err != nil {
+			t.Fatalf("%s: fetchData: %v", tt.name, err)
+		}
+
+		fetchedBulbasaur := false
+		for _, path := range src.fetched {
+			fetchedBulbasaur = fetchedBulbasaur || path == "/pokedex/bulbasaur"
+		}
+		if fetchedBulbasaur == tt.reused {
+			t.Errorf("%s: fetched Bulbasaur's page = %v, want %v", tt.name, fetchedBulbasaur, !tt.reused)
+		}
+		if got := pokemons[0].Name == cached.Name; got != tt.reused {
+			t.Errorf("%s: first entry = %s, reused %v, want %v", tt.name, pokemons[0].Name, got, tt.reused)
+		}
+		if tt.reused && pokemons[0].SpriteURL == "" {
+			t.Errorf("%s: reused entry didn't get the sprite of the national list", tt.name)
+		}
+	}
+}
+
+func TestFetchDataCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pokedex.checkpoint")
+	checkpoint, _, err := OpenCheckpoint(path)
+	if err != nil {
+		t.Fatalf("OpenCheckpoint: %v", err)
+	}
+	defer checkpoint.Remove()
+
+	var skipped SkipReport
+	pokemons, err := fetchData(fixtures, scrapeOptions{Workers: 2, Checkpoint: checkpoint}, &skipped)
+	if err != nil {
+		t.Fatalf("fetchData: %v", err)
+	}
+
+	entries, err := readCheckpoint(path)
+	if err != nil {
+		t.Fatalf("readCheckpoint: %v", err)
+	}
+	if len(entries) != len(pokemons) {
+		t.Fatalf("checkpoint has %d entries, want %d", len(entries), len(pokemons))
+	}
+	for _, p := range pokemons {
+		want, _ := json.Marshal(p)
+		got, _ := json.Marshal(entries[p.ID].Pokemon)
+		if string(got) != string(want) {
+			t.Errorf("checkpoint entry %s =\n%s\nwant\n%s", entryName(p), got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// evoSpecies is a species card in an evolution chart.
+type evoSpecies struct {
+	id   int
+	name string
+}
+
+// parseEvolutions reads the evolution chart of a detail page and returns
+// the steps that lead to or from the species with the given ID.
+func parseEvolutions(doc *goquery.Document, id int) []Evolution {
+	var all []Evolution
+	doc.Find(".infocard-list-evo").Each(func(i int, list *goquery.Selection) {
+		//Nested lists belong to a split and are walked from their parent
+		if list.ParentsFiltered(".infocard-list-evo").Length() > 0 {
+			return
+		}
+		walkEvolutionList(list, nil, &all)
+	})
+
+	var evolutions []Evolution
+	seen := make(map[Evolution]bool)
+	for _, evo := range all {
+		if evo.FromID != id && evo.ToID != id {
+			continue
+		}
+		if seen[evo] {
+			continue
+		}
+		seen[evo] = true
+		evolutions = append(evolutions, evo)
+	}
+	return evolutions
+}
+
+// walkEvolutionList walks the cards and arrows of a chart in order, adding
+// a step to evolutions for every arrow between two cards. Branches
+// (.infocard-evo-split) continue from the last card before them.
+func walkEvolutionList(list *goquery.Selection, prev *evoSpecies, evolutions *[]Evolution) {
+	var condition string
+	var pending bool
+
+	list.Children().Each(func(i int, child *goquery.Selection) {
+		switch {
+		case child.HasClass("infocard-arrow"):
+			condition = strings.Trim(strings.TrimSpace(child.Text()), "()")
+			pending = true
+		case child.HasClass("infocard-evo-split"):
+			child.Find(".infocard-list-evo").Each(func(i int, branch *goquery.Selection) {
+				if branch.ParentsFiltered(".infocard-evo-split").First().IsSelection(child) {
+					walkEvolutionList(branch, prev, evolutions)
+				}
+			})
+		case child.HasClass("infocard"):
+			species, ok := parseEvoSpecies(child)
+			if !ok {
+				return
+			}
+			if pending && prev != nil {
+				*evolutions = append(*evolutions, newEvolution(*prev, species, condition))
+			}
+			prev = &species
+			pending = false
+		}
+	})
+}
+
+// parseEvoSpecies reads the dex number and name of an evolution card.
+func parseEvoSpecies(card *goquery.Selection) (evoSpecies, bool) {
+	label := strings.TrimSpace(card.Find("small").First().Text())
+	id, err := strconv.Atoi(strings.TrimLeft(label, "#0"))
+	name := strings.TrimSpace(card.Find(".ent-name").First().Text())
+	if err != nil || name == "" {
+		return evoSpecies{}, false
+	}
+	return evoSpecies{id: id, name: name}, true
+}
+
+var (
+	levelTrigger = regexp.MustCompile(`^Level (\d+)`)
+	itemTrigger  = regexp.MustCompile(`^use (.+)$`)
+)
+
+// newEvolution builds the step from -> to triggered by condition.
+func newEvolution(from, to evoSpecies, condition string) Evolution {
+	evo := Evolution{
+		FromID:    from.id,
+		From:      from.name,
+		ToID:      to.id,
+		To:        to.name,
+		Trigger:   "other",
+		Condition: condition,
+	}
+
+	lower := strings.ToLower(condition)
+	if m := levelTrigger.FindStringSubmatch(condition); m != nil {
+		evo.Trigger = "level"
+		evo.Level, _ = strconv.Atoi(m[1])
+	} else if m := itemTrigger.FindStringSubmatch(condition); m != nil {
+		evo.Trigger = "item"
+		evo.Item = m[1]
+	} else if strings.HasPrefix(lower, "trade") {
+		evo.Trigger = "trade"
+	} else if strings.Contains(lower, "friendship") {
+		evo.Trigger = "friendship"
+	}
+	return evo
+}
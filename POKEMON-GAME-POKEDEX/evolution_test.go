@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseEvolutions(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		id   int
+		want []Evolution
+	}{
+		{
+			name: "first stage",
+			path: "/pokedex/bulbasaur",
+			id:   1,
+			want: []Evolution{
+				{FromID: 1, From: "Bulbasaur", ToID: 2, To: "Ivysaur", Trigger: "level", Level: 16, Condition: "Level 16"},
+			},
+		},
+		{
+			name: "middle stage",
+			path: "/pokedex/ivysaur",
+			id:   2,
+			want: []Evolution{
+				{FromID: 1, From: "Bulbasaur", ToID: 2, To: "Ivysaur", Trigger: "level", Level: 16, Condition: "Level 16"},
+				{FromID: 2, From: "Ivysaur", ToID: 3, To: "Venusaur", Trigger: "level", Level: 32, Condition: "Level 32"},
+			},
+		},
+		{
+			name: "split",
+			path: "/pokedex/eevee",
+			id:   133,
+			want: []Evolution{
+				{FromID: 133, From: "Eevee", ToID: 134, To: "Vaporeon", Trigger: "item", Item: "Water Stone", Condition: "use Water Stone"},
+				{FromID: 133, From: "Eevee", ToID: 136, To: "Flareon", Trigger: "item", Item: "Fire Stone", Condition: "use Fire Stone"},
+				{FromID: 133, From: "Eevee", ToID: 196, To: "Espeon", Trigger: "friendship", Condition: "high Friendship, Daytime"},
+			},
+		},
+		{
+			name: "branch of a split",
+			path: "/pokedex/eevee",
+			id:   136,
+			want: []Evolution{
+				{FromID: 133, From: "Eevee", ToID: 136, To: "Flareon", Trigger: "item", Item: "Fire Stone", Condition: "use Fire Stone"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := fetchDocument(fixtures, tt.path)
+			if err != nil {
+				t.Fatalf("fetchDocument: %v", err)
+			}
+			if got := parseEvolutions(doc, tt.id); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseEvolutions(%s, %d) =\n%+v\nwant\n%+v", tt.path, tt.id, got, tt.want)
+			}
+		})
+	}
+}
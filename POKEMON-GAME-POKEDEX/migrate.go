@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pokemon-game/dex"
+)
+
+// runMigrate implements "pokedex migrate [-dry-run] file...", which
+// upgrades dex and player files in place to the current schema version.
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report what would change without writing")
+	backup := fs.Bool("backup", true, "keep a copy of each upgraded file as <file>.bak")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: pokedex migrate [-dry-run] [-backup=false] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("no files given")
+	}
+
+	failed := 0
+	for _, file := range fs.Args() {
+		if err := migrateFile(file, *dryRun, *backup); err != nil {
+			fmt.Printf("%s: %v\n", file, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files could not be migrated", failed, fs.NArg())
+	}
+	return nil
+}
+
+// migrateFile upgrades a single dex or player file.
+func migrateFile(file string, dryRun, backup bool) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	version, err := dex.FileVersion(data)
+	if err != nil {
+		return err
+	}
+	if version == dex.SchemaVersion {
+		fmt.Printf("%s: already at schema version %d\n", file, dex.SchemaVersion)
+		return nil
+	}
+	if version != 1 {
+		return fmt.Errorf("unsupported schema version %d (this build reads versions 1-%d)", version, dex.SchemaVersion)
+	}
+
+	key, err := listKey(file, data)
+	if err != nil {
+		return err
+	}
+	list, err := dex.UpgradeList(data, key)
+	if err != nil {
+		return err
+	}
+	upgraded, err := dex.WrapList(key, list, detectIndent(data))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s file, schema version %d -> %d\n", file, strings.TrimSuffix(key, "s"), version, dex.SchemaVersion)
+	if dryRun {
+		return nil
+	}
+	if backup {
+		if err := os.WriteFile(file+".bak", data, 0644); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(file, append(upgraded, '\n'))
+}
+
+// listKey tells dex files from player files. Version 1 player entries have
+// a "pokemon_list"; an empty version 1 file is classified by its name.
+func listKey(file string, data []byte) (string, error) {
+	var entries []map[string]json.RawMessage
+	if err := json.Unmarshal(bytes.TrimSpace(data), &entries); err != nil {
+		return "", err
+	}
+	if len(entries) == 0 {
+		if strings.Contains(strings.ToLower(filepath.Base(file)), "player") {
+			return dex.PlayerListKey, nil
+		}
+		return dex.DexListKey, nil
+	}
+	if _, ok := entries[0]["pokemon_list"]; ok {
+		return dex.PlayerListKey, nil
+	}
+	return dex.DexListKey, nil
+}
+
+// detectIndent returns the indentation unit of a JSON file, taken from its
+// first indented line, so a migrated file keeps its original style.
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			return line[:len(line)-len(trimmed)]
+		}
+	}
+	return "  "
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const movesPath = "/move/all"
+
+// parseLevelUpMoves reads the "Moves learnt by level up" table of a detail
+// page. Only the first table is used, which covers the latest games.
+func parseLevelUpMoves(doc *goquery.Document) []LevelMove {
+	var moves []LevelMove
+
+	doc.Find("h3").EachWithBreak(func(i int, h *goquery.Selection) bool {
+		if strings.TrimSpace(h.Text()) != "Moves learnt by level up" {
+			return true
+		}
+
+		table := h.NextAllFiltered(".resp-scroll").First().Find("table.data-table")
+		table.Find("tbody tr").Each(func(i int, row *goquery.Selection) {
+			cells := row.Find("td")
+			name := strings.TrimSpace(row.Find(".ent-name").Text())
+			if name == "" {
+				return
+			}
+			level, _ := strconv.Atoi(strings.TrimSpace(cells.First().Text()))
+			moves = append(moves, LevelMove{Level: level, Name: name})
+		})
+		return false
+	})
+
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].Level < moves[j].Level })
+	return moves
+}
+
+// fetchMoves scrapes the move list page into the move database.
+func fetchMoves(src PageSource) ([]Move, error) {
+	doc, err := fetchDocument(src, movesPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []Move
+	doc.Find("table#moves tbody tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		if cells.Length() < 6 {
+			return
+		}
+
+		move := Move{
+			Name:     strings.TrimSpace(cells.Eq(0).Text()),
+			Type:     strings.TrimSpace(cells.Eq(1).Text()),
+			Category: moveCategory(cells.Eq(2)),
+			Power:    parseMoveNumber(cells.Eq(3).Text()),
+			Accuracy: parseMoveNumber(cells.Eq(4).Text()),
+			PP:       parseMoveNumber(cells.Eq(5).Text()),
+		}
+		if move.Name == "" {
+			return
+		}
+		moves = append(moves, move)
+	})
+
+	if len(moves) == 0 {
+		return nil, fmt.Errorf("no moves found on %s", movesPath)
+	}
+	return moves, nil
+}
+
+// moveCategory reads the category cell, which shows an icon rather than text.
+func moveCategory(cell *goquery.Selection) string {
+	category, ok := cell.Attr("data-sort-value")
+	if !ok || category == "" {
+		category, _ = cell.Find("img").Attr("title")
+	}
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		category = strings.ToLower(strings.TrimSpace(cell.Text()))
+	}
+	return category
+}
+
+// parseMoveNumber parses a power, accuracy or PP cell. Cells without a
+// number ("—" for status moves, "∞" for moves that never miss) yield 0.
+func parseMoveNumber(value string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0
+	}
+	return n
+}
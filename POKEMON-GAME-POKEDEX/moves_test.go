@@ -0,0 +1,30 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFetchMoves(t *testing.T) {
+	got, err := fetchMoves(fixtures)
+	if err != nil {
+		t.Fatalf("fetchMoves: %v", err)
+	}
+
+	want := []Move{
+		{Name: "Tackle", Type: "Normal", Category: "physical", Power: 40, Accuracy: 100, PP: 35},
+		{Name: "Ember", Type: "Fire", Category: "special", Power: 40, Accuracy: 100, PP: 25},
+		{Name: "Growl", Type: "Normal", Category: "status", Power: 0, Accuracy: 100, PP: 40},
+		{Name: "Swift", Type: "Normal", Category: "special", Power: 60, Accuracy: 0, PP: 20},
+		{Name: "Vine Whip", Type: "Grass", Category: "physical", Power: 45, Accuracy: 100, PP: 25},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fetchMoves =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestFetchMovesEmptyPage(t *testing.T) {
+	if _, err := fetchMoves(&DirSource{Dir: t.TempDir()}); err == nil {
+		t.Error("fetchMoves without a move page succeeded")
+	}
+}
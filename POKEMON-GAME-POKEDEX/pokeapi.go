@@ -0,0 +1,458 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pokemon-game/dex"
+)
+
+// The PokeAPI importer reads a local dump in the layout of the PokeAPI
+// api-data project: one directory per resource kind holding one JSON
+// document per ID, either as <kind>/<id>/index.json or <kind>/<id>.json.
+// It uses the pokemon-species, pokemon, type, evolution-chain and move
+// directories; the last three are optional.
+
+// apiRef is a named link to another resource.
+type apiRef struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// id returns the ID at the end of the reference URL.
+func (r apiRef) id() int {
+	id, _ := strconv.Atoi(path.Base(strings.TrimSuffix(r.URL, "/")))
+	return id
+}
+
+type apiName struct {
+	Name     string `json:"name"`
+	Language apiRef `json:"language"`
+}
+
+type apiSpecies struct {
+	ID             int       `json:"id"`
+	Name           string    `json:"name"`
+	Names          []apiName `json:"names"`
+	CaptureRate    int       `json:"capture_rate"`
+	BaseHappiness  int       `json:"base_happiness"`
+	GenderRate     int       `json:"gender_rate"`
+	GrowthRate     apiRef    `json:"growth_rate"`
+	EggGroups      []apiRef  `json:"egg_groups"`
+	EvolutionChain apiRef    `json:"evolution_chain"`
+	Varieties      []struct {
+		IsDefault bool   `json:"is_default"`
+		Pokemon   apiRef `json:"pokemon"`
+	} `json:"varieties"`
+}
+
+type apiPokemon struct {
+	ID             int `json:"id"`
+	BaseExperience int `json:"base_experience"`
+	Types          []struct {
+		Slot int    `json:"slot"`
+		Type apiRef `json:"type"`
+	} `json:"types"`
+	Stats []struct {
+		BaseStat int    `json:"base_stat"`
+		Effort   int    `json:"effort"`
+		Stat     apiRef `json:"stat"`
+	} `json:"stats"`
+	Abilities []struct {
+		Ability  apiRef `json:"ability"`
+		IsHidden bool   `json:"is_hidden"`
+		Slot     int    `json:"slot"`
+	} `json:"abilities"`
+	Moves []struct {
+		Move                apiRef `json:"move"`
+		VersionGroupDetails []struct {
+			LevelLearnedAt  int    `json:"level_learned_at"`
+			MoveLearnMethod apiRef `json:"move_learn_method"`
+			VersionGroup    apiRef `json:"version_group"`
+		} `json:"version_group_details"`
+	} `json:"moves"`
+	Sprites struct {
+		FrontDefault string `json:"front_default"`
+	} `json:"sprites"`
+}
+
+type apiChainLink struct {
+	Species          apiRef `json:"species"`
+	EvolutionDetails []struct {
+		MinLevel int     `json:"min_level"`
+		Trigger  apiRef  `json:"trigger"`
+		Item     *apiRef `json:"item"`
+	} `json:"evolution_details"`
+	EvolvesTo []apiChainLink `json:"evolves_to"`
+}
+
+type apiMove struct {
+	Name        string    `json:"name"`
+	Names       []apiName `json:"names"`
+	Type        apiRef    `json:"type"`
+	DamageClass apiRef    `json:"damage_class"`
+	Power       *int      `json:"power"`
+	Accuracy    *int      `json:"accuracy"`
+	PP          *int      `json:"pp"`
+	Priority    int       `json:"priority"`
+}
+
+// pokeAPIDump reads resources from a dump directory, caching the names it
+// resolves.
+type pokeAPIDump struct {
+	dir       string
+	typeNames map[string]string
+	moves     map[string]apiMove
+}
+
+// importPokeAPI builds dex entries for the selected species of the dump.
+func importPokeAPI(dir string, sel Selection, skipped *SkipReport) ([]Pokemon, error) {
+	dump := &pokeAPIDump{dir: dir}
+	ids, err := dump.list("pokemon-species")
+	if err != nil {
+		return nil, err
+	}
+	if err := dump.loadTypeNames(); err != nil {
+		return nil, err
+	}
+	if err := dump.loadMoves(); err != nil {
+		return nil, err
+	}
+
+	var pokemons []Pokemon
+	for _, id := range ids {
+		if !sel.Contains(id) {
+			skipped.Add("not selected", fmt.Sprintf("#%d", id))
+			continue
+		}
+		pokemon, err := dump.pokemon(id)
+		if err != nil {
+			skipped.Add("import failed", fmt.Sprintf("#%d (%v)", id, err))
+			continue
+		}
+		fmt.Println("Append pokemon: ", pokemon.Name)
+		pokemons = append(pokemons, pokemon)
+	}
+	return pokemons, nil
+}
+
+// pokemon builds the dex entry of a species from its default variety.
+func (d *pokeAPIDump) pokemon(id int) (Pokemon, error) {
+	var species apiSpecies
+	if err := d.read("pokemon-species", id, &species); err != nil {
+		return Pokemon{}, err
+	}
+
+	pokemonID := species.ID
+	for _, v := range species.Varieties {
+		if v.IsDefault {
+			pokemonID = v.Pokemon.id()
+		}
+	}
+	var data apiPokemon
+	if err := d.read("pokemon", pokemonID, &data); err != nil {
+		return Pokemon{}, err
+	}
+
+	p := Pokemon{
+		ID:             species.ID,
+		Name:           englishName(species.Names, species.Name),
+		BaseExp:        data.BaseExperience,
+		CatchRate:      species.CaptureRate,
+		BaseFriendship: species.BaseHappiness,
+		GrowthRate:     dex.GrowthRateName(species.GrowthRate.Name),
+		SpriteURL:      data.Sprites.FrontDefault,
+	}
+
+	sort.Slice(data.Types, func(i, j int) bool { return data.Types[i].Slot < data.Types[j].Slot })
+	for _, t := range data.Types {
+		p.Type = append(p.Type, d.typeName(t.Type.Name))
+	}
+
+	for _, s := range data.Stats {
+		switch s.Stat.Name {
+		case "hp":
+			p.HP, p.EVYield.HP = s.BaseStat, s.Effort
+		case "attack":
+			p.Attack, p.EVYield.Attack = s.BaseStat, s.Effort
+		case "defense":
+			p.Defense, p.EVYield.Defense = s.BaseStat, s.Effort
+		case "special-attack":
+			p.SpecialAtk, p.EVYield.SpecialAtk = s.BaseStat, s.Effort
+		case "special-defense":
+			p.SpecialDef, p.EVYield.SpecialDef = s.BaseStat, s.Effort
+		case "speed":
+			p.Speed, p.EVYield.Speed = s.BaseStat, s.Effort
+		}
+	}
+	p.EV = float64(p.EVYield.Total())
+
+	if species.GenderRate < 0 {
+		p.Gender.Genderless = true
+	} else {
+		p.Gender.Female = float64(species.GenderRate) * 100 / 8
+		p.Gender.Male = 100 - p.Gender.Female
+	}
+	for _, g := range species.EggGroups {
+		p.EggGroups = append(p.EggGroups, eggGroupName(g.Name))
+	}
+
+	sort.Slice(data.Abilities, func(i, j int) bool { return data.Abilities[i].Slot < data.Abilities[j].Slot })
+	for _, a := range data.Abilities {
+		p.Abilities = append(p.Abilities, Ability{Name: displayName(a.Ability.Name), Hidden: a.IsHidden})
+	}
+
+	//Level-up moves as learnt in the most recent version group listed,
+	//leaving out those only learnt in older games
+	latest := 0
+	for _, m := range data.Moves {
+		for _, detail := range m.VersionGroupDetails {
+			if detail.MoveLearnMethod.Name == "level-up" && detail.VersionGroup.id() > latest {
+				latest = detail.VersionGroup.id()
+			}
+		}
+	}
+	for _, m := range data.Moves {
+		for _, detail := range m.VersionGroupDetails {
+			if detail.MoveLearnMethod.Name == "level-up" && detail.VersionGroup.id() == latest {
+				p.Moves = append(p.Moves, LevelMove{Level: detail.LevelLearnedAt, Name: d.moveName(m.Move.Name)})
+				break
+			}
+		}
+	}
+	sort.SliceStable(p.Moves, func(i, j int) bool { return p.Moves[i].Level < p.Moves[j].Level })
+
+	if chainID := species.EvolutionChain.id(); chainID > 0 {
+		evolutions, err := d.evolutions(chainID, species.ID)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return Pokemon{}, err
+		}
+		p.Evolutions = evolutions
+	}
+	return p, nil
+}
+
+// evolutions returns the steps of a chain leading to or from species id.
+func (d *pokeAPIDump) evolutions(chainID, id int) ([]Evolution, error) {
+	var chain struct {
+		Chain apiChainLink `json:"chain"`
+	}
+	if err := d.read("evolution-chain", chainID, &chain); err != nil {
+		return nil, err
+	}
+
+	var evolutions []Evolution
+	var walk func(link apiChainLink)
+	walk = func(link apiChainLink) {
+		for _, next := range link.EvolvesTo {
+			from := evoSpecies{id: link.Species.id(), name: displayName(link.Species.Name)}
+			to := evoSpecies{id: next.Species.id(), name: displayName(next.Species.Name)}
+			if from.id == id || to.id == id {
+				evolutions = append(evolutions, chainEvolution(from, to, next))
+			}
+			walk(next)
+		}
+	}
+	walk(chain.Chain)
+	return evolutions, nil
+}
+
+// chainEvolution converts the first evolution detail of a chain link.
+func chainEvolution(from, to evoSpecies, link apiChainLink) Evolution {
+	if len(link.EvolutionDetails) == 0 {
+		return newEvolution(from, to, "")
+	}
+	detail := link.EvolutionDetails[0]
+
+	switch {
+	case detail.Trigger.Name == "level-up" && detail.MinLevel > 0:
+		return newEvolution(from, to, fmt.Sprintf("Level %d", detail.MinLevel))
+	case detail.Trigger.Name == "use-item" && detail.Item != nil:
+		return newEvolution(from, to, "use "+displayName(detail.Item.Name))
+	case detail.Trigger.Name == "trade":
+		return newEvolution(from, to, "trade")
+	}
+	return newEvolution(from, to, displayName(detail.Trigger.Name))
+}
+
+// importPokeAPIMoves builds the move database from the dump's move
+// directory.
+func importPokeAPIMoves(dir string) ([]Move, error) {
+	dump := &pokeAPIDump{dir: dir}
+	if err := dump.loadTypeNames(); err != nil {
+		return nil, err
+	}
+	if err := dump.loadMoves(); err != nil {
+		return nil, err
+	}
+	if len(dump.moves) == 0 {
+		return nil, fmt.Errorf("no moves found in %s", filepath.Join(dir, "move"))
+	}
+
+	var moves []Move
+	for identifier, m := range dump.moves {
+		move := Move{
+			Name:     dump.moveName(identifier),
+			Type:     dump.typeName(m.Type.Name),
+			Category: m.DamageClass.Name,
+			Priority: m.Priority,
+		}
+		if m.Power != nil {
+			move.Power = *m.Power
+		}
+		if m.Accuracy != nil {
+			move.Accuracy = *m.Accuracy
+		}
+		if m.PP != nil {
+			move.PP = *m.PP
+		}
+		moves = append(moves, move)
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].Name < moves[j].Name })
+	return moves, nil
+}
+
+// loadMoves reads the move directory, if the dump has one.
+func (d *pokeAPIDump) loadMoves() error {
+	d.moves = make(map[string]apiMove)
+	ids, err := d.list("move")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		var m apiMove
+		if err := d.read("move", id, &m); err != nil {
+			return err
+		}
+		d.moves[m.Name] = m
+	}
+	return nil
+}
+
+// moveName returns the display name of a move, e.g. "U-turn" for "u-turn".
+func (d *pokeAPIDump) moveName(identifier string) string {
+	if m, ok := d.moves[identifier]; ok {
+		return englishName(m.Names, identifier)
+	}
+	return displayName(identifier)
+}
+
+// list returns the IDs of the resources of a kind in ascending order.
+func (d *pokeAPIDump) list(kind string) ([]int, error) {
+	entries, err := os.ReadDir(filepath.Join(d.dir, kind))
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, e := range entries {
+		id, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".json"))
+		if err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// read decodes the resource kind/id into v.
+func (d *pokeAPIDump) read(kind string, id int, v interface{}) error {
+	base := filepath.Join(d.dir, kind, strconv.Itoa(id))
+	data, err := os.ReadFile(filepath.Join(base, "index.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		data, err = os.ReadFile(base + ".json")
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s/%d: %v", kind, id, err)
+	}
+	return nil
+}
+
+// loadTypeNames reads the English type names from the type directory, if
+// the dump has one.
+func (d *pokeAPIDump) loadTypeNames() error {
+	d.typeNames = make(map[string]string)
+	ids, err := d.list("type")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		var t struct {
+			Name  string    `json:"name"`
+			Names []apiName `json:"names"`
+		}
+		if err := d.read("type", id, &t); err != nil {
+			return err
+		}
+		d.typeNames[t.Name] = englishName(t.Names, t.Name)
+	}
+	return nil
+}
+
+// typeName returns the display name of a type, e.g. "Fire" for "fire".
+func (d *pokeAPIDump) typeName(name string) string {
+	if display, ok := d.typeNames[name]; ok {
+		return display
+	}
+	return displayName(name)
+}
+
+// englishName picks the English entry of a names list, falling back to the
+// resource identifier.
+func englishName(names []apiName, identifier string) string {
+	for _, n := range names {
+		if n.Language.Name == "en" {
+			return n.Name
+		}
+	}
+	return displayName(identifier)
+}
+
+// displayName turns an identifier such as "medium-slow" into "Medium Slow".
+func displayName(identifier string) string {
+	words := strings.Split(identifier, "-")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// pokeAPIEggGroups maps PokeAPI egg group identifiers to the names
+// pokemondb shows, so both sources produce the same entries.
+var pokeAPIEggGroups = map[string]string{
+	"plant":         "Grass",
+	"ground":        "Field",
+	"humanshape":    "Human-Like",
+	"indeterminate": "Amorphous",
+	"no-eggs":       "Undiscovered",
+	"water1":        "Water 1",
+	"water2":        "Water 2",
+	"water3":        "Water 3",
+}
+
+func eggGroupName(identifier string) string {
+	if name, ok := pokeAPIEggGroups[identifier]; ok {
+		return name
+	}
+	return displayName(identifier)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// pokeAPIFixtures is a trimmed-down PokeAPI dump using both file layouts.
+const pokeAPIFixtures = "testdata/pokeapi"
+
+func TestImportPokeAPI(t *testing.T) {
+	var skipped SkipReport
+	got, err := importPokeAPI(pokeAPIFixtures, Selection{}, &skipped)
+	if err != nil {
+		t.Fatalf("importPokeAPI: %v", err)
+	}
+
+	want := []Pokemon{
+		{
+			ID: 4, Name: "Charmander", Type: []string{"Fire"},
+			BaseExp: 62, HP: 39, Attack: 52, Defense: 43, SpecialAtk: 60, SpecialDef: 50, Speed: 65,
+			EV:      1,
+			EVYield: EVYield{Speed: 1},
+			// Only the moves of the latest version group, at its levels
+			Moves: []LevelMove{
+				{Level: 1, Name: "Scratch"},
+				{Level: 1, Name: "Growl"},
+				{Level: 4, Name: "Ember"},
+			},
+			Evolutions: []Evolution{
+				{FromID: 4, From: "Charmander", ToID: 5, To: "Charmeleon", Trigger: "level", Level: 16, Condition: "Level 16"},
+			},
+			SpriteURL: "https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/4.png",
+			CatchRate: 45, BaseFriendship: 50, GrowthRate: "Medium Slow",
+			Gender:    Gender{Male: 87.5, Female: 12.5},
+			EggGroups: []string{"Monster", "Dragon"},
+			Abilities: []Ability{{Name: "Blaze"}, {Name: "Solar Power", Hidden: true}},
+		},
+		{
+			ID: 132, Name: "Ditto", Type: []string{"Normal"},
+			BaseExp: 101, HP: 48, Attack: 48, Defense: 48, SpecialAtk: 48, SpecialDef: 48, Speed: 48,
+			EV:        1,
+			EVYield:   EVYield{HP: 1},
+			Moves:     []LevelMove{{Level: 1, Name: "Transform"}},
+			SpriteURL: "https://raw.githubusercontent.com/PokeAPI/sprites/master/sprites/pokemon/132.png",
+			// PokeAPI calls Medium Fast "medium"
+			CatchRate: 35, BaseFriendship: 50, GrowthRate: "Medium Fast",
+			Gender:    Gender{Genderless: true},
+			EggGroups: []string{"Ditto"},
+			Abilities: []Ability{{Name: "Limber"}, {Name: "Imposter", Hidden: true}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("importPokeAPI =\n%+v\nwant\n%+v", got, want)
+	}
+	if skipped.Len() != 0 {
+		t.Errorf("importPokeAPI skipped %d entries", skipped.Len())
+	}
+	if problems := validateDex(got); len(problems) > 0 {
+		t.Errorf("imported entries fail validation: %v", problems)
+	}
+}
+
+func TestImportPokeAPISelection(t *testing.T) {
+	sel, err := parseSelection("", "100-200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skipped SkipReport
+	got, err := importPokeAPI(pokeAPIFixtures, sel, &skipped)
+	if err != nil {
+		t.Fatalf("importPokeAPI: %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "Ditto" {
+		t.Errorf("importPokeAPI with -ids 100-200 = %+v, want only Ditto", got)
+	}
+	if skipped.Len() != 1 {
+		t.Errorf("importPokeAPI skipped %d entries, want 1", skipped.Len())
+	}
+}
+
+func TestImportPokeAPIMissingDump(t *testing.T) {
+	var skipped SkipReport
+	if _, err := importPokeAPI(t.TempDir(), Selection{}, &skipped); err == nil {
+		t.Error("importPokeAPI of an empty directory succeeded")
+	}
+}
+
+func TestImportPokeAPIMoves(t *testing.T) {
+	got, err := importPokeAPIMoves(pokeAPIFixtures)
+	if err != nil {
+		t.Fatalf("importPokeAPIMoves: %v", err)
+	}
+
+	want := []Move{
+		{Name: "Ember", Type: "Fire", Category: "special", Power: 40, Accuracy: 100, PP: 25},
+		{Name: "Growl", Type: "Normal", Category: "status", Power: 0, Accuracy: 100, PP: 40},
+		{Name: "Quick Attack", Type: "Normal", Category: "physical", Power: 40, Accuracy: 100, PP: 30, Priority: 1},
+		{Name: "Scratch", Type: "Normal", Category: "physical", Power: 40, Accuracy: 100, PP: 35},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("importPokeAPIMoves =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if _, err := importPokeAPIMoves(t.TempDir()); err == nil {
+		t.Error("importPokeAPIMoves without a move directory succeeded")
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	tests := []struct {
+		identifier string
+		want       string
+	}{
+		{"medium-slow", "Medium Slow"},
+		{"solar-power", "Solar Power"},
+		{"fire", "Fire"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := displayName(tt.identifier); got != tt.want {
+			t.Errorf("displayName(%q) = %q, want %q", tt.identifier, got, tt.want)
+		}
+	}
+}
@@ -2,129 +2,405 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"pokemon-game/dex"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-type Pokemon struct {
-	ID         int      `json:"id"`
-	Name       string   `json:"name"`
-	Type       []string `json:"type"`
-	BaseExp    int      `json:"base_exp"`
-	Speed      int      `json:"speed"`
-	Attack     int      `json:"attack"`
-	Defense    int      `json:"defense"`
-	SpecialAtk int      `json:"special_atk"`
-	SpecialDef int      `json:"speical_def"`
-	HP         int      `json:"hp"`
-	EV         float64  `json:"ev"`
-}
+// The dex entry types are shared with the game servers through package dex.
+type (
+	Pokemon   = dex.Pokemon
+	EVYield   = dex.EVYield
+	Ability   = dex.Ability
+	Gender    = dex.Gender
+	LevelMove = dex.LevelMove
+	Move      = dex.Move
+	Evolution = dex.Evolution
+)
 
 func main() {
-	pokemonURL := "https://pokemondb.net/pokedex/national"
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	pokemons, err := fetchData(pokemonURL)
+	source := flag.String("source", "pokemondb", "where to read the dex from: pokemondb (scrape the site) or pokeapi (a local PokeAPI data dump, see -dump)")
+	dumpDir := flag.String("dump", "", "PokeAPI data dump directory holding pokemon-species, pokemon, type, evolution-chain and move, for -source pokeapi")
+	offline := flag.String("offline", "", "parse saved pokemondb HTML pages from this directory instead of fetching live")
+	save := flag.String("save", "", "save every fetched page into this directory for later -offline runs")
+	gens := flag.String("gen", "", "only scrape these generations, e.g. 1-3 or 1,4")
+	ids := flag.String("ids", "", "only scrape these national dex IDs, e.g. 1-151,252-386")
+	workers := flag.Int("workers", 4, "number of detail pages fetched concurrently")
+	rps := flag.Float64("rps", 2, "maximum live requests per second (0 for no limit)")
+	retries := flag.Int("retries", 3, "retries for a page after a server error or timeout")
+	timeout := flag.Duration("timeout", 30*time.Second, "timeout for a single live request")
+	cacheDir := flag.String("cache", "cache", "directory caching fetched pages (empty to disable)")
+	maxAge := flag.Duration("max-age", 7*24*time.Hour, "age after which cached pages are revalidated and dex entries refetched")
+	outFile := flag.String("out", "pokedex.json", "dex file to write, its extension replaced per format; entries already in the JSON dex are kept and updated")
+	format := flag.String("format", "json", "comma separated output formats: json, ndjson, csv, sqlite")
+	checkpointFile := flag.String("checkpoint", "pokedex.checkpoint", "file recording progress so an interrupted run can resume")
+	refresh := flag.Bool("refresh", false, "refetch every selected entry even if it is already in the dex (always done with -offline and -source pokeapi)")
+	movesFile := flag.String("moves", "moves.json", "move database to write (empty to skip)")
+	validate := flag.Bool("validate", true, "refuse to write the dex if any entry fails validation")
+	diffFile := flag.String("diff", "", "print the species added, removed and changed compared with this dex")
+	spriteDir := flag.String("sprites", "", "download each species' sprite into this directory, with a manifest.json")
+	flag.Parse()
+
+	sel, err := parseSelection(*gens, *ids)
 	if err != nil {
-		fmt.Println("Error fetching data:", err)
-		return
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+	writers, err := parseFormats(*format)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(2)
+	}
+	switch {
+	case *source != "pokemondb" && *source != "pokeapi":
+		fmt.Printf("Error: unknown -source %q (want pokemondb or pokeapi)\n", *source)
+		os.Exit(2)
+	case *source == "pokeapi" && *dumpDir == "":
+		fmt.Println("Error: -source pokeapi needs -dump <dir>")
+		os.Exit(2)
+	}
+
+	live := &HTTPSource{BaseURL: baseURL, Client: &http.Client{Timeout: *timeout}}
+	var src PageSource = &RetrySource{
+		Source:  NewLimitedSource(live, *rps),
+		Retries: *retries,
+		Backoff: time.Second,
+	}
+	if *cacheDir != "" {
+		live.Cache = &PageCache{Dir: *cacheDir}
+		src = &CacheSource{Source: src, Cache: live.Cache, MaxAge: *maxAge}
+	}
+	if *offline != "" {
+		src = &DirSource{Dir: *offline}
+	}
+	if *save != "" {
+		src = &RecordingSource{Source: src, Dir: *save}
 	}
 
-	file, err := json.MarshalIndent(pokemons, "", "  ")
+	checkpoint, recorded, err := OpenCheckpoint(*checkpointFile)
+	if err != nil {
+		fmt.Println("Error opening checkpoint:", err)
+		return
+	}
+	previous, err := loadPreviousEntries(outputFile(*outFile, jsonWriter{}), recorded)
 	if err != nil {
-		fmt.Println("Error encoding pokemon data:", err)
+		fmt.Println("Error loading previous entries:", err)
 		return
 	}
+	if len(recorded) > 0 {
+		fmt.Printf("Resuming from checkpoint with %d entries\n", len(recorded))
+	}
+
+	opts := scrapeOptions{
+		Selection:  sel,
+		Workers:    *workers,
+		Checkpoint: checkpoint,
+	}
+	//Only live scrapes reuse earlier entries: saved pages and dumps are
+	//cheap to read again, and regenerating from them must not return the
+	//entries of another source
+	if !*refresh && *offline == "" && *source != "pokeapi" {
+		opts.Previous = previous
+		opts.MaxAge = *maxAge
+	}
 
-	err = os.WriteFile("pokedex.json", file, 0644)
+	var skipped SkipReport
+	var pokemons []Pokemon
+	if *source == "pokeapi" {
+		pokemons, err = importPokeAPI(*dumpDir, sel, &skipped)
+	} else {
+		pokemons, err = fetchData(src, opts, &skipped)
+	}
+	skipped.Print()
 	if err != nil {
-		fmt.Println("Error saving data to JSON file:", err)
+		fmt.Println("Error fetching data:", err)
 		return
 	}
 
+	//Date the entries fetched by this run, keeping the dates of reused ones
+	fetchedAt := make(map[int]time.Time, len(previous)+len(pokemons))
+	for id, entry := range previous {
+		fetchedAt[id] = entry.FetchedAt
+	}
+	now := time.Now()
+	for _, pokemon := range pokemons {
+		if _, ok := opts.reuse(pokemon.ID); ok {
+			continue
+		}
+		fetchedAt[pokemon.ID] = now
+	}
+	pokemons = mergeEntries(previous, pokemons)
+
+	if *diffFile != "" {
+		if err := printDiff(*diffFile, pokemons); err != nil {
+			fmt.Println("Error comparing with previous dex:", err)
+		}
+	}
+
+	if problems := validateDex(pokemons); len(problems) > 0 {
+		fmt.Printf("Validation found %d problems:\n", len(problems))
+		for _, problem := range problems {
+			fmt.Println("  " + problem)
+		}
+		if *validate {
+			fmt.Println("Not saving pokemon data; fix the scraper or rerun with -validate=false.")
+			os.Exit(1)
+		}
+	}
+
+	for _, w := range writers {
+		file := outputFile(*outFile, w)
+		if err := w.Write(file, pokemons); err != nil {
+			fmt.Printf("Error saving data to %s: %v\n", file, err)
+			return
+		}
+		fmt.Println("Wrote", file)
+	}
+	if err := saveFetchDates(outputFile(*outFile, jsonWriter{}), fetchedAt); err != nil {
+		fmt.Println("Error saving fetch dates:", err)
+	}
+	if err := checkpoint.Remove(); err != nil {
+		fmt.Println("Error removing checkpoint:", err)
+	}
+
 	fmt.Println("Pokemon data has been saved successfully.")
-}
 
-func fetchData(url string) ([]Pokemon, error) {
-	//GET request
-	res, err := http.Get(url)
-	if err != nil {
-		fmt.Println("failed to fetch HTML from PokemonDB: ", err)
-		return nil, err
+	if *spriteDir != "" {
+		//Sprites live on another host, so they get their own limiter
+		var spriteSrc PageSource
+		if *offline == "" {
+			spriteSrc = &RetrySource{
+				Source:  NewLimitedSource(&HTTPSource{Client: &http.Client{Timeout: *timeout}}, *rps),
+				Retries: *retries,
+				Backoff: time.Second,
+			}
+		}
+		if err := downloadSprites(spriteSrc, pokemons, *spriteDir, *workers); err != nil {
+			fmt.Println("Error downloading sprites:", err)
+		}
 	}
-	defer res.Body.Close()
 
-	//Check the status
-	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	if *movesFile != "" {
+		var moves []Move
+		if *source == "pokeapi" {
+			moves, err = importPokeAPIMoves(*dumpDir)
+		} else {
+			moves, err = fetchMoves(src)
+		}
+		if err != nil {
+			fmt.Println("Error fetching moves:", err)
+			return
+		}
+		data, err := json.MarshalIndent(moves, "", "  ")
+		if err != nil {
+			fmt.Println("Error encoding move data:", err)
+			return
+		}
+		if err := writeFileAtomic(*movesFile, data); err != nil {
+			fmt.Println("Error saving move data:", err)
+			return
+		}
+		fmt.Printf("%d moves have been saved successfully.\n", len(moves))
 	}
+}
+
+// scrapeOptions controls which entries fetchData fetches and how.
+type scrapeOptions struct {
+	Selection Selection
+	Workers   int
+
+	// Previous holds entries from an earlier run; those fetched less than
+	// MaxAge ago are reused instead of refetched.
+	Previous map[int]checkpointEntry
+	MaxAge   time.Duration
+
+	// Checkpoint records each entry as soon as it is fetched.
+	Checkpoint *Checkpoint
+}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+// reuse returns the entry from an earlier run to keep for id instead of
+// fetching it again, if there is one and it is not stale.
+func (opts scrapeOptions) reuse(id int) (checkpointEntry, bool) {
+	prev, ok := opts.Previous[id]
+	return prev, ok && time.Since(prev.FetchedAt) < opts.MaxAge
+}
+
+func fetchData(src PageSource, opts scrapeOptions, skipped *SkipReport) ([]Pokemon, error) {
+	doc, err := fetchDocument(src, nationalPath)
 	if err != nil {
+		fmt.Println("failed to fetch HTML from PokemonDB: ", err)
 		return nil, err
 	}
 
-	//Create empty pokemon list
-	var pokemons []Pokemon
-	var count = 1
+	//Collect the selected entries of the national list
+	var jobs []detailJob
+	var reused []Pokemon
+	seen := make(map[int]bool)
 
 	//Go through over each list item in the list containing Pokemon data
 	doc.Find(".infocard").Each(func(i int, s *goquery.Selection) {
-		if len(pokemons) > 200 {
-			return
-		}
 		var pokemon Pokemon
 
-		//Get pokemon ID String
-		idStr, _ := s.Find(".infocard-cell-data").First().Attr("data-sprite")
-		//Convert ID String into Integer
-		id, _ := strconv.Atoi(strings.TrimPrefix(idStr, "/sprites/"))
-		pokemon.ID = id
-
 		//Get Pokemon name
 		pokemon.Name = strings.TrimSpace(s.Find(".ent-name").Text())
 
+		//Get national dex ID
+		id, err := parseDexID(s)
+		if err != nil {
+			skipped.Add("invalid dex ID", fmt.Sprintf("%s (%v)", pokemon.Name, err))
+			return
+		}
+		pokemon.ID = id
+
+		//Get sprite image URL
+		pokemon.SpriteURL = parseSpriteURL(s)
+
+		if !opts.Selection.Contains(pokemon.ID) {
+			skipped.Add("not selected", entryName(pokemon))
+			return
+		}
+		if seen[pokemon.ID] {
+			skipped.Add("duplicate dex ID", entryName(pokemon))
+			return
+		}
+		seen[pokemon.ID] = true
+
+		//Reuse the entry from an earlier run unless it is stale
+		if prev, ok := opts.reuse(pokemon.ID); ok {
+			if pokemon.SpriteURL != "" {
+				prev.Pokemon.SpriteURL = pokemon.SpriteURL
+			}
+			reused = append(reused, prev.Pokemon)
+			return
+		}
+
 		//Get all Pokemon types
 		s.Find(".itype").Each(func(i int, s *goquery.Selection) {
 			pokemon.Type = append(pokemon.Type, strings.TrimSpace(s.Text()))
 		})
-		pokemon.ID = count
-		count++
 
-		//Get Pokemon detail page URL
-		detailURL, _ := s.Find("a").First().Attr("href")
-		fullDetailURL := "https://pokemondb.net" + detailURL
-
-		//Fetch Pokemon detail data from detail page
-		if err := FetchDetails(fullDetailURL, &pokemon); err != nil {
-			fmt.Printf("Error fetching details for %s: %v\n", pokemon.Name, err)
-			return
-		}
-		fmt.Println("Append pokemon: ", pokemon.Name)
-		pokemons = append(pokemons, pokemon)
+		//Get Pokemon detail page path
+		detailPath, _ := s.Find("a").First().Attr("href")
+		jobs = append(jobs, detailJob{pokemon: pokemon, path: detailPath})
 	})
 
+	if len(reused) > 0 {
+		fmt.Printf("Reusing %d entries from the previous run, fetching %d\n", len(reused), len(jobs))
+	}
+	pokemons := append(reused, fetchAllDetails(src, jobs, opts.Workers, opts.Checkpoint, skipped)...)
+	sort.Slice(pokemons, func(i, j int) bool { return pokemons[i].ID < pokemons[j].ID })
 	return pokemons, nil
 }
 
-func FetchDetails(URL string, pokemon *Pokemon) error {
-	//GET request
-	res, err := http.Get(URL)
-	if err != nil {
-		return err
+// detailJob is a national list entry whose detail page is still to fetch.
+type detailJob struct {
+	pokemon Pokemon
+	path    string
+	err     error
+}
+
+// fetchAllDetails fetches the detail page of every job using a pool of
+// workers, recording each success in checkpoint. The result is sorted by
+// dex ID whatever order pages complete in; entries whose page could not be
+// fetched are added to skipped.
+func fetchAllDetails(src PageSource, jobs []detailJob, workers int, checkpoint *Checkpoint, skipped *SkipReport) []Pokemon {
+	if workers < 1 {
+		workers = 1
 	}
-	defer res.Body.Close()
 
-	//Check the status code
-	if res.StatusCode != http.StatusOK {
-		return fmt.Errorf("status code error: %d %s", res.StatusCode, res.Status)
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				job := &jobs[i]
+				//Fetch Pokemon detail data from detail page
+				if job.err = FetchDetails(src, job.path, &job.pokemon); job.err != nil {
+					fmt.Printf("Error fetching details for %s: %v\n", job.pokemon.Name, job.err)
+					continue
+				}
+				if checkpoint != nil {
+					if err := checkpoint.Record(job.pokemon); err != nil {
+						fmt.Println("Error writing checkpoint:", err)
+					}
+				}
+				fmt.Println("Append pokemon: ", job.pokemon.Name)
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	var pokemons []Pokemon
+	for _, job := range jobs {
+		if job.err != nil {
+			skipped.Add("detail fetch failed", fmt.Sprintf("%s (%v)", entryName(job.pokemon), job.err))
+			continue
+		}
+		pokemons = append(pokemons, job.pokemon)
+	}
+	sort.Slice(pokemons, func(i, j int) bool { return pokemons[i].ID < pokemons[j].ID })
+	return pokemons
+}
+
+// entryName labels a dex entry in progress and skip messages.
+func entryName(pokemon Pokemon) string {
+	return fmt.Sprintf("#%d %s", pokemon.ID, pokemon.Name)
+}
+
+// parseDexID reads the national dex number of an infocard, preferring the
+// data-sprite attribute and falling back to the "#0001" label.
+func parseDexID(s *goquery.Selection) (int, error) {
+	if idStr, ok := s.Find(".infocard-cell-data").First().Attr("data-sprite"); ok {
+		if id, err := strconv.Atoi(strings.TrimPrefix(idStr, "/sprites/")); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+
+	label := strings.TrimSpace(s.Find(".infocard-lg-data small").First().Text())
+	id, err := strconv.Atoi(strings.TrimLeft(label, "#0"))
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("no dex number found")
 	}
+	return id, nil
+}
 
-	doc, err := goquery.NewDocumentFromReader(res.Body)
+// fetchDocument fetches the page at path from src and parses it.
+func fetchDocument(src PageSource, path string) (*goquery.Document, error) {
+	body, err := src.Fetch(path)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	return goquery.NewDocumentFromReader(body)
+}
+
+func FetchDetails(src PageSource, path string, pokemon *Pokemon) error {
+	doc, err := fetchDocument(src, path)
 	if err != nil {
 		return err
 	}
@@ -142,9 +418,25 @@ func FetchDetails(URL string, pokemon *Pokemon) error {
 				pokemon.BaseExp, _ = strconv.Atoi(attrValue)
 				baseExpFound = true
 			case "EV yield":
-				evStr := strings.Fields(attrValue)[0]
-				pokemon.EV, _ = strconv.ParseFloat(evStr, 64)
+				ev, err := parseEVYield(attrValue)
+				if err != nil {
+					return
+				}
+				pokemon.EVYield = ev
+				pokemon.EV = float64(ev.Total())
 				evFound = true
+			case "Catch rate":
+				pokemon.CatchRate, _ = leadingInt(attrValue)
+			case "Base Friendship":
+				pokemon.BaseFriendship, _ = leadingInt(attrValue)
+			case "Growth Rate":
+				pokemon.GrowthRate = attrValue
+			case "Gender":
+				pokemon.Gender, _ = parseGender(attrValue)
+			case "Egg Groups":
+				pokemon.EggGroups = parseLinkList(row.Find("td"))
+			case "Abilities":
+				pokemon.Abilities = parseAbilities(row.Find("td"))
 			default:
 				//Check if attrValue contains stats
 				statParts := strings.Split(attrValue, "\n")
@@ -178,5 +470,42 @@ func FetchDetails(URL string, pokemon *Pokemon) error {
 		return fmt.Errorf("all required data are not fetched successfully")
 	}
 
+	pokemon.Moves = parseLevelUpMoves(doc)
+	pokemon.Evolutions = parseEvolutions(doc, pokemon.ID)
+
 	return nil
 }
+
+// parseEVYield parses the "EV yield" cell, e.g. "1 Special Attack" or
+// "2 Attack, 1 Speed".
+func parseEVYield(value string) (EVYield, error) {
+	var ev EVYield
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			return ev, fmt.Errorf("bad EV yield %q", value)
+		}
+		amount, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return ev, fmt.Errorf("bad EV yield %q", value)
+		}
+
+		switch strings.Join(fields[1:], " ") {
+		case "HP":
+			ev.HP += amount
+		case "Attack", "Atk":
+			ev.Attack += amount
+		case "Defense", "Def":
+			ev.Defense += amount
+		case "Special Attack", "Sp. Atk", "Sp.Atk":
+			ev.SpecialAtk += amount
+		case "Special Defense", "Sp. Def", "Sp.Def":
+			ev.SpecialDef += amount
+		case "Speed", "Spe":
+			ev.Speed += amount
+		default:
+			return ev, fmt.Errorf("unknown stat in EV yield %q", value)
+		}
+	}
+	return ev, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fixtures holds trimmed-down pokemondb pages in the layout DirSource reads.
+var fixtures = &DirSource{Dir: "testdata/pages"}
+
+func TestFetchDetails(t *testing.T) {
+	gender := Gender{Male: 87.5, Female: 12.5}
+	tests := []struct {
+		path string
+		want Pokemon
+	}{
+		{
+			path: "/pokedex/bulbasaur",
+			want: Pokemon{
+				ID: 1, Name: "Bulbasaur",
+				BaseExp: 64, HP: 45, Attack: 49, Defense: 49, SpecialAtk: 65, SpecialDef: 65, Speed: 45,
+				EV:      1,
+				EVYield: EVYield{SpecialAtk: 1},
+				Moves: []LevelMove{
+					{Level: 1, Name: "Tackle"},
+					{Level: 1, Name: "Growl"},
+					{Level: 3, Name: "Leech Seed"},
+					{Level: 6, Name: "Vine Whip"},
+				},
+				Evolutions: []Evolution{
+					{FromID: 1, From: "Bulbasaur", ToID: 2, To: "Ivysaur", Trigger: "level", Level: 16, Condition: "Level 16"},
+				},
+				CatchRate: 45, BaseFriendship: 50, GrowthRate: "Medium Slow", Gender: gender,
+				EggGroups: []string{"Grass", "Monster"},
+				Abilities: []Ability{{Name: "Overgrow"}, {Name: "Chlorophyll", Hidden: true}},
+			},
+		},
+		{
+			path: "/pokedex/eevee",
+			want: Pokemon{
+				ID: 133, Name: "Eevee",
+				BaseExp: 65, HP: 55, Attack: 55, Defense: 50, SpecialAtk: 45, SpecialDef: 65, Speed: 55,
+				EV:      1,
+				EVYield: EVYield{SpecialDef: 1},
+				Moves: []LevelMove{
+					{Level: 1, Name: "Tackle"},
+					{Level: 1, Name: "Tail Whip"},
+					{Level: 5, Name: "Sand Attack"},
+					{Level: 10, Name: "Quick Attack"},
+				},
+				Evolutions: []Evolution{
+					{FromID: 133, From: "Eevee", ToID: 134, To: "Vaporeon", Trigger: "item", Item: "Water Stone", Condition: "use Water Stone"},
+					{FromID: 133, From: "Eevee", ToID: 136, To: "Flareon", Trigger: "item", Item: "Fire Stone", Condition: "use Fire Stone"},
+					{FromID: 133, From: "Eevee", ToID: 196, To: "Espeon", Trigger: "friendship", Condition: "high Friendship, Daytime"},
+				},
+				CatchRate: 45, BaseFriendship: 50, GrowthRate: "Medium Fast", Gender: gender,
+				EggGroups: []string{"Field"},
+				Abilities: []Ability{{Name: "Run Away"}, {Name: "Adaptability"}, {Name: "Anticipation", Hidden: true}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := Pokemon{ID: tt.want.ID, Name: tt.want.Name}
+			if err := FetchDetails(fixtures, tt.path, &got); err != nil {
+				t.Fatalf("FetchDetails: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FetchDetails(%s) =\n%+v\nwant\n%+v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchDetailsMissingPage(t *testing.T) {
+	var pokemon Pokemon
+	if err := FetchDetails(fixtures, "/pokedex/missingno", &pokemon); err == nil {
+		t.Error("FetchDetails of a missing page succeeded")
+	}
+}
+
+func TestParseEVYield(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    EVYield
+		wantErr bool
+	}{
+		{value: "1 Special Attack", want: EVYield{SpecialAtk: 1}},
+		{value: "2 Attack, 1 Speed", want: EVYield{Attack: 2, Speed: 1}},
+		{value: "1 Sp. Atk, 1 Sp. Def", want: EVYield{SpecialAtk: 1, SpecialDef: 1}},
+		{value: "3 HP", want: EVYield{HP: 3}},
+		{value: "1 Def, 1 Spe", want: EVYield{Defense: 1, Speed: 1}},
+		{value: "", wantErr: true},
+		{value: "Attack", wantErr: true},
+		{value: "1 Luck", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEVYield(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEVYield(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseEVYield(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestFetchData(t *testing.T) {
+	var skipped SkipReport
+	pokemons, err := fetchData(fixtures, scrapeOptions{Selection: Selection{}, Workers: 2}, &skipped)
+	if err != nil {
+		t.Fatalf("fetchData: %v", err)
+	}
+
+	var got []string
+	for _, p := range pokemons {
+		got = append(got, entryName(p))
+	}
+	want := []string{"#1 Bulbasaur", "#2 Ivysaur", "#133 Eevee"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("fetchData entries = %v, want %v", got, want)
+	}
+
+	ivysaur := pokemons[1]
+	if want := (EVYield{SpecialAtk: 1, SpecialDef: 1}); ivysaur.EVYield != want || ivysaur.EV != 2 {
+		t.Errorf("Ivysaur EV yield = %+v (EV %v), want %+v (EV 2)", ivysaur.EVYield, ivysaur.EV, want)
+	}
+	if want := []string{"Grass", "Poison"}; !reflect.DeepEqual(ivysaur.Type, want) {
+		t.Errorf("Ivysaur types = %v, want %v", ivysaur.Type, want)
+	}
+	if want := "https://img.pokemondb.net/sprites/scarlet-violet/normal/ivysaur.png"; ivysaur.SpriteURL != want {
+		t.Errorf("Ivysaur sprite = %q, want %q", ivysaur.SpriteURL, want)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// idRange is an inclusive range of national dex IDs.
+type idRange struct {
+	From int
+	To   int
+}
+
+// generations holds the national dex range introduced by each generation,
+// indexed by generation number minus one.
+var generations = []idRange{
+	{1, 151},
+	{152, 251},
+	{252, 386},
+	{387, 493},
+	{494, 649},
+	{650, 721},
+	{722, 809},
+	{810, 905},
+	{906, 1025},
+}
+
+// Selection is the set of national dex IDs to scrape. The zero Selection
+// selects every species.
+type Selection struct {
+	ranges []idRange
+}
+
+// Contains reports whether id is selected.
+func (s Selection) Contains(id int) bool {
+	if len(s.ranges) == 0 {
+		return true
+	}
+	for _, r := range s.ranges {
+		if id >= r.From && id <= r.To {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSelection builds a Selection from the -gen and -ids flag values.
+// Both may be given, in which case their union is selected.
+func parseSelection(gens, ids string) (Selection, error) {
+	var sel Selection
+
+	if gens != "" {
+		genRanges, err := parseRanges(gens)
+		if err != nil {
+			return sel, fmt.Errorf("invalid -gen %q: %v", gens, err)
+		}
+		for _, r := range genRanges {
+			if r.From < 1 || r.To > len(generations) {
+				return sel, fmt.Errorf("invalid -gen %q: generations are 1-%d", gens, len(generations))
+			}
+			sel.ranges = append(sel.ranges, idRange{generations[r.From-1].From, generations[r.To-1].To})
+		}
+	}
+
+	if ids != "" {
+		idRanges, err := parseRanges(ids)
+		if err != nil {
+			return sel, fmt.Errorf("invalid -ids %q: %v", ids, err)
+		}
+		sel.ranges = append(sel.ranges, idRanges...)
+	}
+
+	sort.Slice(sel.ranges, func(i, j int) bool { return sel.ranges[i].From < sel.ranges[j].From })
+	return sel, nil
+}
+
+// parseRanges parses a comma separated list of numbers and inclusive
+// ranges, e.g. "1-151,252-386,493".
+func parseRanges(s string) ([]idRange, error) {
+	var ranges []idRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		from, to, isRange := strings.Cut(part, "-")
+
+		first, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("bad number in %q", part)
+		}
+		last := first
+		if isRange {
+			last, err = strconv.Atoi(strings.TrimSpace(to))
+			if err != nil {
+				return nil, fmt.Errorf("bad number in %q", part)
+			}
+		}
+		if first < 1 || last < first {
+			return nil, fmt.Errorf("bad range %q", part)
+		}
+		ranges = append(ranges, idRange{first, last})
+	}
+	return ranges, nil
+}
+
+// SkipReport records the dex entries that were not written and why.
+type SkipReport struct {
+	reasons map[string][]string
+	order   []string
+}
+
+// Add records that entry was skipped for reason.
+func (r *SkipReport) Add(reason, entry string) {
+	if r.reasons == nil {
+		r.reasons = make(map[string][]string)
+	}
+	if _, ok := r.reasons[reason]; !ok {
+		r.order = append(r.order, reason)
+	}
+	r.reasons[reason] = append(r.reasons[reason], entry)
+}
+
+// Len returns the number of skipped entries.
+func (r *SkipReport) Len() int {
+	n := 0
+	for _, entries := range r.reasons {
+		n += len(entries)
+	}
+	return n
+}
+
+// Print writes a summary grouped by reason. Long groups are truncated
+// since "not selected" alone can cover most of the national dex.
+func (r *SkipReport) Print() {
+	if r.Len() == 0 {
+		return
+	}
+	fmt.Printf("Skipped %d entries:\n", r.Len())
+	for _, reason := range r.order {
+		entries := r.reasons[reason]
+		shown := entries
+		if len(shown) > 10 {
+			shown = shown[:10]
+		}
+		line := strings.Join(shown, ", ")
+		if len(entries) > len(shown) {
+			line += fmt.Sprintf(", ... (%d more)", len(entries)-len(shown))
+		}
+		fmt.Printf("  %s (%d): %s\n", reason, len(entries), line)
+	}
+}
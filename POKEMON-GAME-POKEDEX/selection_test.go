@@ -0,0 +1,90 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSelection(t *testing.T) {
+	tests := []struct {
+		gens    string
+		ids     string
+		want    []idRange
+		wantErr bool
+	}{
+		{want: nil},
+		{gens: "1", want: []idRange{{1, 151}}},
+		{gens: "1-2", want: []idRange{{1, 251}}},
+		{gens: "3,1", want: []idRange{{1, 151}, {252, 386}}},
+		{gens: "9", want: []idRange{{906, 1025}}},
+		{ids: "25", want: []idRange{{25, 25}}},
+		{ids: "1-3, 7 ,150-151", want: []idRange{{1, 3}, {7, 7}, {150, 151}}},
+		{gens: "2", ids: "25", want: []idRange{{25, 25}, {152, 251}}},
+		{gens: "0", wantErr: true},
+		{gens: "10", wantErr: true},
+		{gens: "x", wantErr: true},
+		{ids: "5-3", wantErr: true},
+		{ids: "0", wantErr: true},
+		{ids: "1,,2", wantErr: true},
+		{ids: "1-", wantErr: true},
+		{ids: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseSelection(tt.gens, tt.ids)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSelection(%q, %q) error = %v, want error %v", tt.gens, tt.ids, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got.ranges, tt.want) {
+			t.Errorf("parseSelection(%q, %q) = %v, want %v", tt.gens, tt.ids, got.ranges, tt.want)
+		}
+	}
+}
+
+func TestSelectionContains(t *testing.T) {
+	sel, err := parseSelection("1", "200-202")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		id   int
+		want bool
+	}{
+		{1, true},
+		{151, true},
+		{152, false},
+		{199, false},
+		{200, true},
+		{202, true},
+		{203, false},
+	}
+	for _, tt := range tests {
+		if got := sel.Contains(tt.id); got != tt.want {
+			t.Errorf("Contains(%d) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+
+	if !(Selection{}).Contains(1025) {
+		t.Error("the zero Selection doesn't contain #1025")
+	}
+}
+
+func TestFetchDataSelection(t *testing.T) {
+	sel, err := parseSelection("", "2-100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var skipped SkipReport
+	pokemons, err := fetchData(fixtures, scrapeOptions{Selection: sel, Workers: 1}, &skipped)
+	if err != nil {
+		t.Fatalf("fetchData: %v", err)
+	}
+	if len(pokemons) != 1 || pokemons[0].Name != "Ivysaur" {
+		t.Errorf("fetchData with -ids 2-100 = %+v, want only Ivysaur", pokemons)
+	}
+	if skipped.Len() != 2 {
+		t.Errorf("fetchData skipped %d entries, want 2", skipped.Len())
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	baseURL      = "https://pokemondb.net"
+	nationalPath = "/pokedex/national"
+)
+
+// PageSource returns the HTML of a pokemondb page keyed by its URL path,
+// e.g. "/pokedex/national" or "/pokedex/bulbasaur".
+type PageSource interface {
+	Fetch(path string) (io.ReadCloser, error)
+}
+
+// HTTPSource fetches pages live from pokemondb.net, or any absolute URL
+// when BaseURL is empty. When Cache is set, pages already in it are
+// requested conditionally and every fetched page is stored back with its
+// validators.
+type HTTPSource struct {
+	BaseURL string
+	Client  *http.Client
+	Cache   *PageCache
+}
+
+func (s *HTTPSource) Fetch(path string) (io.ReadCloser, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, s.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	//Revalidate a cached copy instead of downloading it again
+	var cached []byte
+	var meta cacheMeta
+	var haveCached bool
+	if s.Cache != nil {
+		cached, meta, haveCached = s.Cache.Load(path)
+		if haveCached && meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
+		}
+		if haveCached && meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
+
+	//GET request
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified && haveCached {
+		meta.FetchedAt = time.Now()
+		if err := s.Cache.Store(path, cached, meta); err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(cached)), nil
+	}
+
+	//Check the status
+	if res.StatusCode != http.StatusOK {
+		return nil, &StatusError{Code: res.StatusCode, Status: res.Status}
+	}
+
+	//Read the whole body here so a timeout mid-page can be retried
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Cache != nil {
+		meta := cacheMeta{
+			URL:          req.URL.String(),
+			ETag:         res.Header.Get("ETag"),
+			LastModified: res.Header.Get("Last-Modified"),
+			FetchedAt:    time.Now(),
+		}
+		if err := s.Cache.Store(path, data, meta); err != nil {
+			return nil, err
+		}
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// StatusError is returned by HTTPSource for non-200 responses.
+type StatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status code error: %d %s", e.Code, e.Status)
+}
+
+// DirSource reads pages saved on disk. The page for "/pokedex/bulbasaur"
+// is expected at <Dir>/pokedex/bulbasaur.html.
+type DirSource struct {
+	Dir string
+}
+
+func (s *DirSource) Fetch(path string) (io.ReadCloser, error) {
+	return os.Open(pageFile(s.Dir, path))
+}
+
+// RecordingSource saves every page fetched from Source into Dir using the
+// layout DirSource reads, so a live run can be replayed with -offline.
+type RecordingSource struct {
+	Source PageSource
+	Dir    string
+}
+
+func (s *RecordingSource) Fetch(path string) (io.ReadCloser, error) {
+	body, err := s.Source.Fetch(path)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	file := pageFile(s.Dir, path)
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// LimitedSource spaces out fetches from Source to at most RPS requests per
+// second across all goroutines. An RPS of zero or less disables the limit.
+type LimitedSource struct {
+	Source PageSource
+	ticker *time.Ticker
+}
+
+func NewLimitedSource(src PageSource, rps float64) *LimitedSource {
+	s := &LimitedSource{Source: src}
+	if rps > 0 {
+		s.ticker = time.NewTicker(time.Duration(float64(time.Second) / rps))
+	}
+	return s
+}
+
+func (s *LimitedSource) Fetch(path string) (io.ReadCloser, error) {
+	if s.ticker != nil {
+		<-s.ticker.C
+	}
+	return s.Source.Fetch(path)
+}
+
+// RetrySource retries fetches from Source that failed with a server error
+// or a timeout, waiting Backoff, 2*Backoff, 4*Backoff... (with jitter)
+// between attempts.
+type RetrySource struct {
+	Source  PageSource
+	Retries int
+	Backoff time.Duration
+}
+
+func (s *RetrySource) Fetch(path string) (io.ReadCloser, error) {
+	delay := s.Backoff
+	for attempt := 0; ; attempt++ {
+		body, err := s.Source.Fetch(path)
+		if err == nil || attempt >= s.Retries || !isRetryable(err) {
+			return body, err
+		}
+
+		wait := delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+		fmt.Printf("Retrying %s in %v after error: %v\n", path, wait.Round(time.Millisecond), err)
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
+
+// isRetryable reports whether err is worth retrying: 5xx and 429
+// responses, and network timeouts.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= 500 || statusErr.Code == http.StatusTooManyRequests
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// pageFile maps a URL path to the file holding its saved HTML.
+func pageFile(dir, path string) string {
+	path = strings.Trim(path, "/")
+	return filepath.Join(dir, filepath.FromSlash(path)+".html")
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const spriteManifestFile = "manifest.json"
+
+// SpriteEntry is the manifest record of one downloaded sprite. File is
+// relative to the asset directory holding the manifest.
+type SpriteEntry struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	File   string `json:"file"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// SpriteManifest lists the sprites in an asset directory, sorted by dex ID.
+type SpriteManifest struct {
+	Sprites []SpriteEntry `json:"sprites"`
+}
+
+// parseSpriteURL reads the sprite of an infocard, from its image or, on
+// older markup, from the data-sprite attribute when that holds an image path.
+func parseSpriteURL(s *goquery.Selection) string {
+	img := s.Find(".infocard-lg-img img, img.img-sprite").First()
+	src, ok := img.Attr("data-src")
+	if !ok || src == "" {
+		src, _ = img.Attr("src")
+	}
+	if src == "" {
+		sprite, _ := s.Find(".infocard-cell-data").First().Attr("data-sprite")
+		if path.Ext(sprite) != "" {
+			src = sprite
+		}
+	}
+
+	switch {
+	case src == "":
+		return ""
+	case strings.HasPrefix(src, "//"):
+		return "https:" + src
+	case strings.HasPrefix(src, "/"):
+		return baseURL + src
+	}
+	return src
+}
+
+// downloadSprites saves the sprite of every entry into dir and writes its
+// manifest. Sprites already in the manifest with the same URL, and still
+// matching their checksum, are kept without refetching. A nil src only
+// keeps what is already there, for offline runs.
+func downloadSprites(src PageSource, pokemons []Pokemon, dir string, workers int) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	existing, err := loadSpriteManifest(filepath.Join(dir, spriteManifestFile))
+	if err != nil {
+		return err
+	}
+
+	var (
+		mu      sync.Mutex
+		entries []SpriteEntry
+		failed  []string
+	)
+	queue := make(chan Pokemon)
+	var wg sync.WaitGroup
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range queue {
+				entry, err := fetchSprite(src, p, dir, existing[p.ID])
+				mu.Lock()
+				if err != nil {
+					failed = append(failed, fmt.Sprintf("%s (%v)", entryName(p), err))
+				} else {
+					entries = append(entries, entry)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, p := range pokemons {
+		if p.SpriteURL != "" {
+			queue <- p
+			continue
+		}
+		//Keep a sprite saved earlier for an entry whose URL is now unknown
+		if prev, ok := existing[p.ID]; ok {
+			if sum, _, err := fileChecksum(filepath.Join(dir, prev.File)); err == nil && sum == prev.SHA256 {
+				mu.Lock()
+				entries = append(entries, prev)
+				mu.Unlock()
+			}
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+	data, err := json.MarshalIndent(SpriteManifest{Sprites: entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dir, spriteManifestFile), data); err != nil {
+		return err
+	}
+
+	fmt.Printf("Sprites: %d in %s\n", len(entries), dir)
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return fmt.Errorf("%d sprites could not be saved: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// fetchSprite saves the sprite of p unless prev already holds it.
+func fetchSprite(src PageSource, p Pokemon, dir string, prev SpriteEntry) (SpriteEntry, error) {
+	entry := SpriteEntry{ID: p.ID, Name: p.Name, File: spriteFileName(p), URL: p.SpriteURL}
+	file := filepath.Join(dir, entry.File)
+
+	if prev.URL == entry.URL && prev.File == entry.File {
+		if sum, size, err := fileChecksum(file); err == nil && sum == prev.SHA256 {
+			entry.SHA256, entry.Size = sum, size
+			return entry, nil
+		}
+	}
+	if src == nil {
+		return entry, errors.New("not downloaded yet and running offline")
+	}
+
+	body, err := src.Fetch(entry.URL)
+	if err != nil {
+		return entry, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return entry, err
+	}
+	if err := writeFileAtomic(file, data); err != nil {
+		return entry, err
+	}
+
+	sum := sha256.Sum256(data)
+	entry.SHA256 = hex.EncodeToString(sum[:])
+	entry.Size = int64(len(data))
+	return entry, nil
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// spriteFileName names a sprite after its dex entry, keeping the image
+// extension of its URL, e.g. "0001-bulbasaur.avif".
+func spriteFileName(p Pokemon) string {
+	ext := ".png"
+	if u, err := url.Parse(p.SpriteURL); err == nil && path.Ext(u.Path) != "" {
+		ext = path.Ext(u.Path)
+	}
+	slug := strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(p.Name), "-"), "-")
+	return fmt.Sprintf("%04d-%s%s", p.ID, slug, ext)
+}
+
+// loadSpriteManifest reads a manifest keyed by dex ID. A missing manifest
+// is empty.
+func loadSpriteManifest(file string) (map[int]SpriteEntry, error) {
+	entries := make(map[int]SpriteEntry)
+	data, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest SpriteManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("reading %s: %v", file, err)
+	}
+	for _, e := range manifest.Sprites {
+		entries[e.ID] = e
+	}
+	return entries, nil
+}
+
+// fileChecksum returns the hex SHA-256 and size of file.
+func fileChecksum(file string) (string, int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"pokemon-game/dex"
+)
+
+// knownTypes lists the 18 Pokemon types.
+var knownTypes = map[string]bool{
+	"Normal": true, "Fire": true, "Water": true, "Electric": true, "Grass": true, "Ice": true,
+	"Fighting": true, "Poison": true, "Ground": true, "Flying": true, "Psychic": true, "Bug": true,
+	"Rock": true, "Ghost": true, "Dragon": true, "Dark": true, "Steel": true, "Fairy": true,
+}
+
+// validateDex checks the scraped entries for the symptoms of a selector no
+// longer matching the site: zero stats, missing or unknown types, duplicate
+// entries and values outside what any species has. It returns one message
+// per violation.
+func validateDex(pokemons []Pokemon) []string {
+	var problems []string
+	ids := make(map[int]string)
+	names := make(map[string]int)
+
+	for _, p := range pokemons {
+		report := func(format string, args ...interface{}) {
+			problems = append(problems, entryName(p)+": "+fmt.Sprintf(format, args...))
+		}
+
+		if p.ID <= 0 {
+			report("invalid dex ID")
+		} else if other, ok := ids[p.ID]; ok {
+			report("dex ID already used by %s", other)
+		}
+		ids[p.ID] = p.Name
+
+		if p.Name == "" {
+			report("empty name")
+		} else if other, ok := names[strings.ToLower(p.Name)]; ok {
+			report("name already used by #%d", other)
+		}
+		names[strings.ToLower(p.Name)] = p.ID
+
+		if len(p.Type) < 1 || len(p.Type) > 2 {
+			report("has %d types, want 1 or 2", len(p.Type))
+		}
+		for i, t := range p.Type {
+			if !knownTypes[t] {
+				report("unknown type %q", t)
+			}
+			if i > 0 && t == p.Type[0] {
+				report("type %q listed twice", t)
+			}
+		}
+
+		stats := []struct {
+			name  string
+			value int
+		}{
+			{"hp", p.HP},
+			{"attack", p.Attack},
+			{"defense", p.Defense},
+			{"special_atk", p.SpecialAtk},
+			{"special_def", p.SpecialDef},
+			{"speed", p.Speed},
+		}
+		total := 0
+		for _, stat := range stats {
+			if stat.value < 1 || stat.value > 255 {
+				report("%s %d outside 1-255", stat.name, stat.value)
+			}
+			total += stat.value
+		}
+		if total < 150 || total > 800 {
+			report("base stat total %d outside 150-800", total)
+		}
+
+		if p.BaseExp < 0 || p.BaseExp > 700 {
+			report("base_exp %d outside 0-700", p.BaseExp)
+		}
+		// Entries from before per-stat yields were scraped only have the total
+		ev := p.EVYield.Total()
+		if p.EVYield == (EVYield{}) {
+			ev = int(p.EV)
+		}
+		if ev < 1 || ev > 3 {
+			report("EV yield total %d outside 1-3", ev)
+		}
+		if p.CatchRate < 0 || p.CatchRate > 255 {
+			report("catch_rate %d outside 0-255", p.CatchRate)
+		}
+	}
+	return problems
+}
+
+// printDiff compares pokemons with the dex in oldFile and prints the species
+// added, removed and changed, with the fields that changed.
+func printDiff(oldFile string, pokemons []Pokemon) error {
+	data, err := os.ReadFile(oldFile)
+	if err != nil {
+		return err
+	}
+	var old []Pokemon
+	if err := dex.DecodeVersioned(data, dex.DexListKey, &old); err != nil {
+		return fmt.Errorf("reading %s: %v", oldFile, err)
+	}
+
+	oldByID := make(map[int]Pokemon, len(old))
+	for _, p := range old {
+		oldByID[p.ID] = p
+	}
+	newByID := make(map[int]Pokemon, len(pokemons))
+	for _, p := range pokemons {
+		newByID[p.ID] = p
+	}
+
+	var added, removed, changed []string
+	for _, p := range pokemons {
+		prev, ok := oldByID[p.ID]
+		if !ok {
+			added = append(added, entryName(p))
+			continue
+		}
+		if fields := changedFields(prev, p); len(fields) > 0 {
+			changed = append(changed, entryName(p)+": "+strings.Join(fields, ", "))
+		}
+	}
+	for _, p := range old {
+		if _, ok := newByID[p.ID]; !ok {
+			removed = append(removed, entryName(p))
+		}
+	}
+
+	fmt.Printf("Diff against %s: %d added, %d removed, %d changed\n", oldFile, len(added), len(removed), len(changed))
+	for _, entry := range added {
+		fmt.Println("  + " + entry)
+	}
+	for _, entry := range removed {
+		fmt.Println("  - " + entry)
+	}
+	for _, entry := range changed {
+		fmt.Println("  ~ " + entry)
+	}
+	return nil
+}
+
+// changedFields lists the JSON fields that differ between two entries as
+// "field old -> new".
+func changedFields(old, new Pokemon) []string {
+	oldFields, newFields := jsonFields(old), jsonFields(new)
+
+	keys := make(map[string]bool)
+	for k := range oldFields {
+		keys[k] = true
+	}
+	for k := range newFields {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var fields []string
+	for _, k := range sorted {
+		o, n := oldFields[k], newFields[k]
+		if bytes.Equal(o, n) {
+			continue
+		}
+		fields = append(fields, fmt.Sprintf("%s %s -> %s", k, shorten(o), shorten(n)))
+	}
+	return fields
+}
+
+// jsonFields returns the encoded value of every JSON field of p.
+func jsonFields(p Pokemon) map[string]json.RawMessage {
+	data, _ := json.Marshal(p)
+	var fields map[string]json.RawMessage
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// shorten abbreviates long values such as move lists in diff output.
+func shorten(value json.RawMessage) string {
+	if value == nil {
+		return "(none)"
+	}
+	s := string(value)
+	if len(s) > 60 {
+		return s[:57] + "..."
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// validEntry returns an entry that passes validateDex.
+func validEntry(id int, name string) Pokemon {
+	return Pokemon{
+		ID: id, Name: name, Type: []string{"Grass", "Poison"},
+		BaseExp: 64, HP: 45, Attack: 49, Defense: 49, SpecialAtk: 65, SpecialDef: 65, Speed: 45,
+		EV: 1, EVYield: EVYield{SpecialAtk: 1}, CatchRate: 45,
+	}
+}
+
+func TestValidateDex(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(p *Pokemon)
+		want   string
+	}{
+		{name: "valid", change: func(p *Pokemon) {}},
+		{name: "zero stat", change: func(p *Pokemon) { p.Attack = 0 }, want: "attack 0 outside 1-255"},
+		{name: "stat too high", change: func(p *Pokemon) { p.HP = 256 }, want: "hp 256 outside 1-255"},
+		{name: "highest stat", change: func(p *Pokemon) { p.HP = 255 }},
+		{name: "low total", change: func(p *Pokemon) {
+			p.HP, p.Attack, p.Defense, p.SpecialAtk, p.SpecialDef, p.Speed = 20, 20, 20, 20, 20, 20
+		}, want: "base stat total 120 outside 150-800"},
+		{name: "high total", change: func(p *Pokemon) {
+			p.HP, p.Attack, p.Defense, p.SpecialAtk, p.SpecialDef, p.Speed = 150, 150, 150, 150, 150, 150
+		}, want: "base stat total 900 outside 150-800"},
+		{name: "no type", change: func(p *Pokemon) { p.Type = nil }, want: "has 0 types"},
+		{name: "three types", change: func(p *Pokemon) { p.Type = []string{"Fire", "Water", "Grass"} }, want: "has 3 types"},
+		{name: "unknown type", change: func(p *Pokemon) { p.Type = []string{"Sound"} }, want: `unknown type "Sound"`},
+		{name: "lower case type", change: func(p *Pokemon) { p.Type = []string{"grass"} }, want: `unknown type "grass"`},
+		{name: "type twice", change: func(p *Pokemon) { p.Type = []string{"Fire", "Fire"} }, want: `type "Fire" listed twice`},
+		{name: "no EV yield", change: func(p *Pokemon) { p.EV, p.EVYield = 0, EVYield{} }, want: "EV yield total 0 outside 1-3"},
+		{name: "EV yield too high", change: func(p *Pokemon) { p.EVYield = EVYield{HP: 2, Attack: 2} }, want: "EV yield total 4 outside 1-3"},
+		{name: "EV total only", change: func(p *Pokemon) { p.EV, p.EVYield = 3, EVYield{} }},
+		{name: "bad base exp", change: func(p *Pokemon) { p.BaseExp = 701 }, want: "base_exp 701 outside 0-700"},
+		{name: "bad catch rate", change: func(p *Pokemon) { p.CatchRate = 300 }, want: "catch_rate 300 outside 0-255"},
+		{name: "bad ID", change: func(p *Pokemon) { p.ID = 0 }, want: "invalid dex ID"},
+		{name: "no name", change: func(p *Pokemon) { p.Name = "" }, want: "empty name"},
+	}
+
+	for _, tt := range tests {
+		p := validEntry(1, "Bulbasaur")
+		tt.change(&p)
+		problems := validateDex([]Pokemon{p})
+		if tt.want == "" {
+			if len(problems) > 0 {
+				t.Errorf("%s: validateDex = %v, want no problems", tt.name, problems)
+			}
+			continue
+		}
+		if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+			t.Errorf("%s: validateDex = %v, want one problem containing %q", tt.name, problems, tt.want)
+		}
+	}
+}
+
+func TestValidateDexDuplicates(t *testing.T) {
+	pokemons := []Pokemon{
+		validEntry(1, "Bulbasaur"),
+		validEntry(2, "Ivysaur"),
+		validEntry(1, "Venusaur"),
+		validEntry(3, "ivysaur"),
+	}
+
+	problems := validateDex(pokemons)
+	want := []string{
+		"#1 Venusaur: dex ID already used by Bulbasaur",
+		"#3 ivysaur: name already used by #2",
+	}
+	if strings.Join(problems, "\n") != strings.Join(want, "\n") {
+		t.Errorf("validateDex =\n%s\nwant\n%s", strings.Join(problems, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateDexFixtures(t *testing.T) {
+	var skipped SkipReport
+	pokemons, err := fetchData(fixtures, scrapeOptions{Workers: 2}, &skipped)
+	if err != nil {
+		t.Fatalf("fetchData: %v", err)
+	}
+	if problems := validateDex(pokemons); len(problems) > 0 {
+		t.Errorf("scraped fixtures fail validation: %v", problems)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// leadingInt parses the number a cell starts with, e.g. 45 from
+// "45 (5.9% with PokéBall, full HP)".
+func leadingInt(value string) (int, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(fields[0])
+	return n, err == nil
+}
+
+// parseGender parses the "Gender" cell, e.g. "87.5% male, 12.5% female"
+// or "Genderless".
+func parseGender(value string) (Gender, bool) {
+	var gender Gender
+	if strings.EqualFold(strings.TrimSpace(value), "Genderless") {
+		gender.Genderless = true
+		return gender, true
+	}
+
+	found := false
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			continue
+		}
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[0], "%"), 64)
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(fields[1]) {
+		case "male":
+			gender.Male = percent
+			found = true
+		case "female":
+			gender.Female = percent
+			found = true
+		}
+	}
+	return gender, found
+}
+
+// parseLinkList returns the text of every link in a cell, e.g. the egg
+// groups in "Grass, Monster".
+func parseLinkList(cell *goquery.Selection) []string {
+	var names []string
+	cell.Find("a").Each(func(i int, a *goquery.Selection) {
+		if name := strings.TrimSpace(a.Text()); name != "" {
+			names = append(names, name)
+		}
+	})
+	return names
+}
+
+// parseAbilities parses the "Abilities" cell. Regular abilities are listed
+// as "1. Overgrow", the hidden one inside a <small> marked "(hidden ability)".
+func parseAbilities(cell *goquery.Selection) []Ability {
+	var abilities []Ability
+	cell.Find("a").Each(func(i int, a *goquery.Selection) {
+		name := strings.TrimSpace(a.Text())
+		if name == "" {
+			return
+		}
+		hidden := a.ParentsFiltered("small").Length() > 0
+		abilities = append(abilities, Ability{Name: name, Hidden: hidden})
+	})
+	return abilities
+}
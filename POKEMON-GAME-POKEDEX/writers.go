@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pokemon-game/dex"
+
+	_ "modernc.org/sqlite"
+)
+
+// DexWriter writes the dex to a file in one output format.
+type DexWriter interface {
+	// Ext is the file extension used for this format, e.g. ".csv".
+	Ext() string
+	Write(file string, pokemons []Pokemon) error
+}
+
+// dexWriters holds the writer of each -format name.
+var dexWriters = map[string]DexWriter{
+	"json":   jsonWriter{},
+	"ndjson": ndjsonWriter{},
+	"csv":    csvWriter{},
+	"sqlite": sqliteWriter{},
+}
+
+// parseFormats parses the -format flag, a comma separated list of formats.
+func parseFormats(value string) ([]DexWriter, error) {
+	var writers []DexWriter
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		w, ok := dexWriters[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown format %q (want json, ndjson, csv or sqlite)", name)
+		}
+		writers = append(writers, w)
+	}
+	return writers, nil
+}
+
+// outputFile returns the file a writer writes to: base with its extension
+// replaced by the writer's.
+func outputFile(base string, w DexWriter) string {
+	return strings.TrimSuffix(base, filepath.Ext(base)) + w.Ext()
+}
+
+// jsonWriter writes the versioned JSON dex read by the servers.
+type jsonWriter struct{}
+
+func (jsonWriter) Ext() string { return ".json" }
+
+func (jsonWriter) Write(file string, pokemons []Pokemon) error {
+	data, err := dex.EncodeVersioned(dex.DexListKey, pokemons)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(file, data)
+}
+
+// ndjsonWriter writes one JSON entry per line.
+type ndjsonWriter struct{}
+
+func (ndjsonWriter) Ext() string { return ".ndjson" }
+
+func (ndjsonWriter) Write(file string, pokemons []Pokemon) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, p := range pokemons {
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(file, buf.Bytes())
+}
+
+// csvWriter writes one row per species. Lists are joined with ";" and
+// hidden abilities are suffixed with "*". Moves and evolutions do not fit
+// a flat row and are left out.
+type csvWriter struct{}
+
+func (csvWriter) Ext() string { return ".csv" }
+
+var csvHeader = []string{
+	"id", "name", "type1", "type2",
+	"hp", "attack", "defense", "special_atk", "special_def", "speed",
+	"base_exp", "ev_hp", "ev_attack", "ev_defense", "ev_special_atk", "ev_special_def", "ev_speed",
+	"catch_rate", "base_friendship", "growth_rate",
+	"male", "female", "genderless", "egg_groups", "abilities",
+}
+
+func (csvWriter) Write(file string, pokemons []Pokemon) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, p := range pokemons {
+		types := append(append([]string{}, p.Type...), "", "")
+		var abilities []string
+		for _, a := range p.Abilities {
+			if a.Hidden {
+				abilities = append(abilities, a.Name+"*")
+			} else {
+				abilities = append(abilities, a.Name)
+			}
+		}
+
+		row := []string{
+			strconv.Itoa(p.ID), p.Name, types[0], types[1],
+			strconv.Itoa(p.HP), strconv.Itoa(p.Attack), strconv.Itoa(p.Defense),
+			strconv.Itoa(p.SpecialAtk), strconv.Itoa(p.SpecialDef), strconv.Itoa(p.Speed),
+			strconv.Itoa(p.BaseExp),
+			strconv.Itoa(p.EVYield.HP), strconv.Itoa(p.EVYield.Attack), strconv.Itoa(p.EVYield.Defense),
+			strconv.Itoa(p.EVYield.SpecialAtk), strconv.Itoa(p.EVYield.SpecialDef), strconv.Itoa(p.EVYield.Speed),
+			strconv.Itoa(p.CatchRate), strconv.Itoa(p.BaseFriendship), p.GrowthRate,
+			strconv.FormatFloat(p.Gender.Male, 'f', -1, 64),
+			strconv.FormatFloat(p.Gender.Female, 'f', -1, 64),
+			strconv.FormatBool(p.Gender.Genderless),
+			strings.Join(p.EggGroups, ";"), strings.Join(abilities, ";"),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeFileAtomic(file, buf.Bytes())
+}
+
+// sqliteWriter writes a SQLite database with a species table and child
+// tables for types, abilities, egg groups, level-up moves and evolutions,
+// indexed for lookups by name and type.
+type sqliteWriter struct{}
+
+func (sqliteWriter) Ext() string { return ".db" }
+
+var sqliteSchema = []string{
+	`CREATE TABLE meta (key TEXT PRIMARY KEY, value TEXT NOT NULL)`,
+	`CREATE TABLE species (
+		id INTEGER PRIMARY KEY,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		hp INTEGER NOT NULL,
+		attack INTEGER NOT NULL,
+		defense INTEGER NOT NULL,
+		special_atk INTEGER NOT NULL,
+		special_def INTEGER NOT NULL,
+		speed INTEGER NOT NULL,
+		base_exp INTEGER NOT NULL,
+		ev_hp INTEGER NOT NULL,
+		ev_attack INTEGER NOT NULL,
+		ev_defense INTEGER NOT NULL,
+		ev_special_atk INTEGER NOT NULL,
+		ev_special_def INTEGER NOT NULL,
+		ev_speed INTEGER NOT NULL,
+		catch_rate INTEGER NOT NULL,
+		base_friendship INTEGER NOT NULL,
+		growth_rate TEXT NOT NULL,
+		male REAL NOT NULL,
+		female REAL NOT NULL,
+		genderless INTEGER NOT NULL
+	)`,
+	`CREATE TABLE species_types (
+		species_id INTEGER NOT NULL REFERENCES species(id),
+		slot INTEGER NOT NULL,
+		type TEXT NOT NULL,
+		PRIMARY KEY (species_id, slot)
+	)`,
+	`CREATE INDEX species_types_type ON species_types(type)`,
+	`CREATE TABLE species_abilities (
+		species_id INTEGER NOT NULL REFERENCES species(id),
+		name TEXT NOT NULL,
+		hidden INTEGER NOT NULL
+	)`,
+	`CREATE INDEX species_abilities_name ON species_abilities(name)`,
+	`CREATE TABLE species_egg_groups (
+		species_id INTEGER NOT NULL REFERENCES species(id),
+		egg_group TEXT NOT NULL
+	)`,
+	`CREATE TABLE level_moves (
+		species_id INTEGER NOT NULL REFERENCES species(id),
+		level INTEGER NOT NULL,
+		move TEXT NOT NULL
+	)`,
+	`CREATE INDEX level_moves_species ON level_moves(species_id, level)`,
+	`CREATE TABLE evolutions (
+		from_id INTEGER NOT NULL,
+		to_id INTEGER NOT NULL,
+		trigger TEXT NOT NULL,
+		level INTEGER,
+		item TEXT,
+		condition TEXT,
+		PRIMARY KEY (from_id, to_id, condition)
+	)`,
+}
+
+func (sqliteWriter) Write(file string, pokemons []Pokemon) error {
+	//Build the database next to the target and swap it in when complete
+	tmp := file + ".tmp"
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+
+	db, err := sql.Open("sqlite", tmp)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range sqliteSchema {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES ('schema_version', ?)`, strconv.Itoa(dex.SchemaVersion)); err != nil {
+		return err
+	}
+
+	for _, p := range pokemons {
+		if err := insertSpecies(tx, p); err != nil {
+			return fmt.Errorf("%s: %v", entryName(p), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	if err := db.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// insertSpecies inserts p and its child rows.
+func insertSpecies(tx *sql.Tx, p Pokemon) error {
+	_, err := tx.Exec(`INSERT INTO species VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		p.ID, p.Name, p.HP, p.Attack, p.Defense, p.SpecialAtk, p.SpecialDef, p.Speed, p.BaseExp,
+		p.EVYield.HP, p.EVYield.Attack, p.EVYield.Defense, p.EVYield.SpecialAtk, p.EVYield.SpecialDef, p.EVYield.Speed,
+		p.CatchRate, p.BaseFriendship, p.GrowthRate, p.Gender.Male, p.Gender.Female, p.Gender.Genderless)
+	if err != nil {
+		return err
+	}
+
+	for i, t := range p.Type {
+		if _, err := tx.Exec(`INSERT INTO species_types VALUES (?, ?, ?)`, p.ID, i+1, t); err != nil {
+			return err
+		}
+	}
+	for _, a := range p.Abilities {
+		if _, err := tx.Exec(`INSERT INTO species_abilities VALUES (?, ?, ?)`, p.ID, a.Name, a.Hidden); err != nil {
+			return err
+		}
+	}
+	for _, g := range p.EggGroups {
+		if _, err := tx.Exec(`INSERT INTO species_egg_groups VALUES (?, ?)`, p.ID, g); err != nil {
+			return err
+		}
+	}
+	for _, m := range p.Moves {
+		if _, err := tx.Exec(`INSERT INTO level_moves VALUES (?, ?, ?)`, p.ID, m.Level, m.Name); err != nil {
+			return err
+		}
+	}
+	//Each step of a chain is listed on every species it touches
+	for _, e := range p.Evolutions {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO evolutions VALUES (?, ?, ?, ?, ?, ?)`,
+			e.FromID, e.ToID, e.Trigger, e.Level, e.Item, e.Condition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"pokemon-game/dex"
+)
+
+// writerFixtures returns entries exercising every field the writers handle.
+func writerFixtures() []Pokemon {
+	bulbasaur := validEntry(1, "Bulbasaur")
+	bulbasaur.GrowthRate = "Medium Slow"
+	bulbasaur.BaseFriendship = 50
+	bulbasaur.Gender = Gender{Male: 87.5, Female: 12.5}
+	bulbasaur.EggGroups = []string{"Grass", "Monster"}
+	bulbasaur.Abilities = []Ability{{Name: "Overgrow"}, {Name: "Chlorophyll", Hidden: true}}
+	bulbasaur.Moves = []LevelMove{{Level: 1, Name: "Tackle"}, {Level: 3, Name: "Leech Seed"}}
+	bulbasaur.Evolutions = []Evolution{
+		{FromID: 1, From: "Bulbasaur", ToID: 2, To: "Ivysaur", Trigger: "level", Level: 16, Condition: "Level 16"},
+	}
+
+	ditto := validEntry(132, "Ditto")
+	ditto.Type = []string{"Normal"}
+	ditto.EVYield = EVYield{HP: 1}
+	ditto.Gender = Gender{Genderless: true}
+	return []Pokemon{bulbasaur, ditto}
+}
+
+func TestParseFormats(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "json", want: []string{".json"}},
+		{value: "json, csv,sqlite,ndjson", want: []string{".json", ".csv", ".db", ".ndjson"}},
+		{value: "xml", wantErr: true},
+		{value: "json,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		writers, err := parseFormats(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFormats(%q) error = %v, want error %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		var got []string
+		for _, w := range writers {
+			got = append(got, w.Ext())
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFormats(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if got := outputFile("out/pokedex.json", csvWriter{}); got != "out/pokedex.csv" {
+		t.Errorf("outputFile = %q, want out/pokedex.csv", got)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pokedex.json")
+	want := writerFixtures()
+	if err := (jsonWriter{}).Write(file, want); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	d, err := dex.Load(file)
+	if err != nil {
+		t.Fatalf("dex.Load: %v", err)
+	}
+	if !reflect.DeepEqual(d.Pokemons, want) {
+		t.Errorf("read back\n%+v\nwant\n%+v", d.Pokemons, want)
+	}
+}
+
+func TestNDJSONWriter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pokedex.ndjson")
+	want := writerFixtures()
+	if err := (ndjsonWriter{}).Write(file, want); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []Pokemon
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var p Pokemon
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			t.Fatalf("line %d: %v", len(got)+1, err)
+		}
+		got = append(got, p)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read back\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pokedex.csv")
+	if err := (csvWriter{}).Write(file, writerFixtures()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+
+	want := [][]string{
+		csvHeader,
+		{"1", "Bulbasaur", "Grass", "Poison", "45", "49", "49", "65", "65", "45", "64",
+			"0", "0", "0", "1", "0", "0", "45", "50", "Medium Slow",
+			"87.5", "12.5", "false", "Grass;Monster", "Overgrow;Chlorophyll*"},
+		{"132", "Ditto", "Normal", "", "45", "49", "49", "65", "65", "45", "64",
+			"1", "0", "0", "0", "0", "0", "45", "0", "",
+			"0", "0", "true", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("CSV rows =\n%q\nwant\n%q", rows, want)
+	}
+}
+
+func TestSQLiteWriter(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pokedex.db")
+	// Writing twice replaces the database rather than failing on it
+	for i := 0; i < 2; i++ {
+		if err := (sqliteWriter{}).Write(file, writerFixtures()); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`SELECT value FROM meta WHERE key = 'schema_version'`, "2"},
+		{`SELECT COUNT(*) FROM species`, "2"},
+		{`SELECT name FROM species WHERE name = 'bulbasaur'`, "Bulbasaur"},
+		{`SELECT growth_rate FROM species WHERE id = 1`, "Medium Slow"},
+		{`SELECT genderless FROM species WHERE id = 132`, "1"},
+		{`SELECT group_concat(type, ',') FROM (SELECT type FROM species_types WHERE species_id = 1 ORDER BY slot)`, "Grass,Poison"},
+		{`SELECT species_id FROM species_types WHERE type = 'Normal'`, "132"},
+		{`SELECT name FROM species_abilities WHERE species_id = 1 AND hidden = 1`, "Chlorophyll"},
+		{`SELECT COUNT(*) FROM species_egg_groups WHERE species_id = 1`, "2"},
+		{`SELECT move FROM level_moves WHERE species_id = 1 AND level = 3`, "Leech Seed"},
+		{`SELECT level FROM evolutions WHERE from_id = 1 AND to_id = 2`, "16"},
+	}
+	for _, tt := range tests {
+		var got string
+		if err := db.QueryRow(tt.query).Scan(&got); err != nil {
+			t.Errorf("%s: %v", tt.query, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	if _, err := os.Stat(file + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary database left behind: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package dex
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// Dex is a loaded list of species with lookups by ID, name and type. When
+// a file lists a species more than once, lookups return the first entry.
+type Dex struct {
+	Pokemons []Pokemon
+
+	byID   map[int]int
+	byName map[string]int
+}
+
+// New indexes pokemons for lookup.
+func New(pokemons []Pokemon) *Dex {
+	d := &Dex{
+		Pokemons: pokemons,
+		byID:     make(map[int]int, len(pokemons)),
+		byName:   make(map[string]int, len(pokemons)),
+	}
+	for i, p := range pokemons {
+		if _, ok := d.byID[p.ID]; !ok {
+			d.byID[p.ID] = i
+		}
+		name := strings.ToLower(p.Name)
+		if _, ok := d.byName[name]; !ok {
+			d.byName[name] = i
+		}
+	}
+	return d
+}
+
+// Load reads a dex file of any supported schema version.
+func Load(file string) (*Dex, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data)
+}
+
+// Decode parses the contents of a dex file of any supported schema version.
+func Decode(data []byte) (*Dex, error) {
+	var pokemons []Pokemon
+	if err := DecodeVersioned(data, DexListKey, &pokemons); err != nil {
+		return nil, err
+	}
+	return New(pokemons), nil
+}
+
+// Encode returns pokemons as a dex file of the current schema version.
+func Encode(pokemons []Pokemon) ([]byte, error) {
+	return EncodeVersioned(DexListKey, pokemons)
+}
+
+// Len returns the number of entries.
+func (d *Dex) Len() int {
+	return len(d.Pokemons)
+}
+
+// ByID returns the species with the given national dex ID.
+func (d *Dex) ByID(id int) (Pokemon, bool) {
+	i, ok := d.byID[id]
+	if !ok {
+		return Pokemon{}, false
+	}
+	return d.Pokemons[i], true
+}
+
+// ByName returns the species with the given name, ignoring case.
+func (d *Dex) ByName(name string) (Pokemon, bool) {
+	i, ok := d.byName[strings.ToLower(name)]
+	if !ok {
+		return Pokemon{}, false
+	}
+	return d.Pokemons[i], true
+}
+
+// ByType returns the species having type t, in dex order.
+func (d *Dex) ByType(t string) []Pokemon {
+	var matches []Pokemon
+	for _, p := range d.Pokemons {
+		if p.HasType(t) {
+			matches = append(matches, p)
+		}
+	}
+	return matches
+}
+
+// Move is an entry of the move database written by the scraper. Category
+// is "physical", "special" or "status"; an Accuracy of 0 means the move
+// never misses. Priority is only known for moves imported from PokeAPI.
+type Move struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Category string `json:"category"`
+	Power    int    `json:"power"`
+	Accuracy int    `json:"accuracy"`
+	PP       int    `json:"pp"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// LoadMoves reads a move database, keyed by lower-case move name.
+func LoadMoves(file string) (map[string]Move, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var moves []Move
+	if err := json.Unmarshal(data, &moves); err != nil {
+		return nil, err
+	}
+
+	movedex := make(map[string]Move, len(moves))
+	for _, m := range moves {
+		movedex[strings.ToLower(m.Name)] = m
+	}
+	return movedex, nil
+}
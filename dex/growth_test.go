@@ -0,0 +1,107 @@
+package dex
+
+import "testing"
+
+func TestExpForLevel(t *testing.T) {
+	tests := []struct {
+		rate  string
+		level int
+		want  int
+	}{
+		{GrowthErratic, 1, 0},
+		{GrowthErratic, 2, 15},
+		{GrowthErratic, 50, 125000},
+		{GrowthErratic, 70, 276458},
+		{GrowthErratic, 100, 600000},
+		{GrowthFast, 1, 0},
+		{GrowthFast, 50, 100000},
+		{GrowthFast, 100, 800000},
+		{GrowthMediumFast, 1, 0},
+		{GrowthMediumFast, 50, 125000},
+		{GrowthMediumFast, 100, 1000000},
+		{GrowthMediumSlow, 1, 0},
+		{GrowthMediumSlow, 2, 9},
+		{GrowthMediumSlow, 50, 117360},
+		{GrowthMediumSlow, 100, 1059860},
+		{GrowthSlow, 1, 0},
+		{GrowthSlow, 50, 156250},
+		{GrowthSlow, 100, 1250000},
+		{GrowthFluctuating, 1, 0},
+		{GrowthFluctuating, 2, 4},
+		{GrowthFluctuating, 20, 5440},
+		{GrowthFluctuating, 50, 142500},
+		{GrowthFluctuating, 100, 1640000},
+		// Levels outside 1-100 are clamped
+		{GrowthSlow, 0, 0},
+		{GrowthSlow, 120, 1250000},
+		// Records without a growth rate are Medium Fast
+		{"", 50, 125000},
+	}
+
+	for _, tt := range tests {
+		if got := ExpForLevel(tt.rate, tt.level); got != tt.want {
+			t.Errorf("ExpForLevel(%q, %d) = %d, want %d", tt.rate, tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestExpForLevelIncreases(t *testing.T) {
+	rates := []string{GrowthErratic, GrowthFast, GrowthMediumFast, GrowthMediumSlow, GrowthSlow, GrowthFluctuating}
+	for _, rate := range rates {
+		for level := 2; level <= MaxLevel; level++ {
+			if ExpForLevel(rate, level) <= ExpForLevel(rate, level-1) {
+				t.Errorf("%s: level %d needs %d, no more than level %d", rate, level, ExpForLevel(rate, level), level-1)
+			}
+		}
+	}
+}
+
+func TestLevelForExp(t *testing.T) {
+	tests := []struct {
+		rate string
+		exp  int
+		want int
+	}{
+		{GrowthMediumFast, 0, 1},
+		{GrowthMediumFast, 7, 1},
+		{GrowthMediumFast, 8, 2},
+		{GrowthMediumFast, 124999, 49},
+		{GrowthMediumFast, 125000, 50},
+		{GrowthMediumFast, 5000000, 100},
+		{GrowthErratic, 600000, 100},
+		{GrowthFluctuating, 142500, 50},
+		{GrowthMediumSlow, 117359, 49},
+	}
+
+	for _, tt := range tests {
+		if got := LevelForExp(tt.rate, tt.exp); got != tt.want {
+			t.Errorf("LevelForExp(%q, %d) = %d, want %d", tt.rate, tt.exp, got, tt.want)
+		}
+	}
+}
+
+func TestGrowthRateName(t *testing.T) {
+	tests := []struct {
+		rate string
+		want string
+	}{
+		{"Erratic", GrowthErratic},
+		{"slow-then-very-fast", GrowthErratic},
+		{"Fast", GrowthFast},
+		{"medium", GrowthMediumFast},
+		{"Medium Fast", GrowthMediumFast},
+		{"medium-slow", GrowthMediumSlow},
+		{" Medium  Slow ", GrowthMediumSlow},
+		{"slow", GrowthSlow},
+		{"fast-then-very-slow", GrowthFluctuating},
+		{"Fluctuating", GrowthFluctuating},
+		{"", GrowthMediumFast},
+		{"unknown", GrowthMediumFast},
+	}
+
+	for _, tt := range tests {
+		if got := GrowthRateName(tt.rate); got != tt.want {
+			t.Errorf("GrowthRateName(%q) = %q, want %q", tt.rate, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+// Package dex holds the species data shared by the Pokedex scraper and the
+// game servers: the dex entry type, the versioned JSON codec of dex and
+// player files, and lookups over a loaded dex.
+package dex
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pokemon is the dex entry of a species, as written by the scraper.
+type Pokemon struct {
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Type       []string    `json:"type"`
+	BaseExp    int         `json:"base_exp"`
+	Speed      int         `json:"speed"`
+	Attack     int         `json:"attack"`
+	Defense    int         `json:"defense"`
+	SpecialAtk int         `json:"special_atk"`
+	SpecialDef int         `json:"special_def"`
+	HP         int         `json:"hp"`
+	EV         float64     `json:"ev"`
+	EVYield    EVYield     `json:"ev_yield"`
+	Moves      []LevelMove `json:"moves,omitempty"`
+	Evolutions []Evolution `json:"evolutions,omitempty"`
+	SpriteURL  string      `json:"sprite_url,omitempty"`
+
+	CatchRate      int       `json:"catch_rate"`
+	BaseFriendship int       `json:"base_friendship"`
+	GrowthRate     string    `json:"growth_rate"`
+	Gender         Gender    `json:"gender"`
+	EggGroups      []string  `json:"egg_groups,omitempty"`
+	Abilities      []Ability `json:"abilities,omitempty"`
+}
+
+// HasType reports whether the species has type t, ignoring case.
+func (p Pokemon) HasType(t string) bool {
+	for _, own := range p.Type {
+		if strings.EqualFold(own, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// EVYield is the effort values a Pokemon gives for each stat when it is
+// defeated. EV holds their total for readers of the older format; entries
+// written before per-stat yields were scraped have a zero EVYield.
+type EVYield struct {
+	HP         int `json:"hp"`
+	Attack     int `json:"attack"`
+	Defense    int `json:"defense"`
+	SpecialAtk int `json:"special_atk"`
+	SpecialDef int `json:"special_def"`
+	Speed      int `json:"speed"`
+}
+
+// Total returns the sum of the yields of all stats.
+func (ev EVYield) Total() int {
+	return ev.HP + ev.Attack + ev.Defense + ev.SpecialAtk + ev.SpecialDef + ev.Speed
+}
+
+// String lists the non-zero yields, e.g. "2 Atk, 1 Spe".
+func (ev EVYield) String() string {
+	var parts []string
+	for _, stat := range []struct {
+		name  string
+		value int
+	}{
+		{"HP", ev.HP},
+		{"Atk", ev.Attack},
+		{"Def", ev.Defense},
+		{"SpA", ev.SpecialAtk},
+		{"SpD", ev.SpecialDef},
+		{"Spe", ev.Speed},
+	} {
+		if stat.value > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", stat.value, stat.name))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Ability is one of the abilities a species can have.
+type Ability struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// Gender is the gender ratio of a species, in percent.
+type Gender struct {
+	Male       float64 `json:"male"`
+	Female     float64 `json:"female"`
+	Genderless bool    `json:"genderless,omitempty"`
+}
+
+// LevelMove is a move a species learns on reaching Level. Level 0 marks a
+// move learnt on evolution.
+type LevelMove struct {
+	Level int    `json:"level"`
+	Name  string `json:"name"`
+}
+
+// Evolution is one step of an evolution chain.
+type Evolution struct {
+	FromID int    `json:"from_id"`
+	From   string `json:"from"`
+	ToID   int    `json:"to_id"`
+	To     string `json:"to"`
+
+	// Trigger is "level", "item", "trade", "friendship" or "other".
+	Trigger string `json:"trigger"`
+	Level   int    `json:"level,omitempty"`
+	Item    string `json:"item,omitempty"`
+
+	// Condition is the condition as shown on the site, e.g. "Level 36, in Galar".
+	Condition string `json:"condition,omitempty"`
+}
+
+// EvolutionAt returns the level-up evolution a Pokemon of this species
+// undergoes at level, if any.
+func (p Pokemon) EvolutionAt(level int) (Evolution, bool) {
+	for _, evo := range p.Evolutions {
+		if evo.FromID == p.ID && evo.Trigger == "level" && evo.Level <= level {
+			return evo, true
+		}
+	}
+	return Evolution{}, false
+}
+
+// MovesAt returns the moves a Pokemon of this species knows at level: the
+// last four distinct moves learnt by level up, as in the games.
+func (p Pokemon) MovesAt(level int) []string {
+	var known []string
+	for _, m := range p.Moves {
+		if m.Level > level {
+			break
+		}
+		for i, name := range known {
+			if name == m.Name {
+				known = append(known[:i], known[i+1:]...)
+				break
+			}
+		}
+		known = append(known, m.Name)
+	}
+	if len(known) > 4 {
+		known = known[len(known)-4:]
+	}
+	return known
+}
@@ -0,0 +1,120 @@
+package dex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// SchemaVersion is the layout of the dex and player files. Version 1 files
+// are bare JSON arrays whose dex entries spell the special defense key
+// "speical_def". Version 2 wraps the list in an object with a
+// schema_version field, under "pokemons" in dex files and "players" in
+// player files, and spells the key "special_def". Older files are upgraded
+// when loaded; "pokedex migrate" upgrades them on disk.
+const SchemaVersion = 2
+
+const (
+	DexListKey    = "pokemons"
+	PlayerListKey = "players"
+)
+
+// FileVersion reports the schema version of a dex or player file.
+func FileVersion(data []byte) (int, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		return 1, nil
+	}
+
+	var header struct {
+		SchemaVersion *int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return 0, err
+	}
+	if header.SchemaVersion == nil {
+		return 0, fmt.Errorf("missing schema_version")
+	}
+	return *header.SchemaVersion, nil
+}
+
+// DecodeVersioned decodes the list stored under key in a dex or player file
+// into v. Version 1 files are upgraded on the fly; files from a newer or
+// unknown version are rejected rather than decoded with missing fields.
+func DecodeVersioned(data []byte, key string, v interface{}) error {
+	list, err := UpgradeList(data, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(list, v)
+}
+
+// EncodeVersioned encodes v as the list stored under key in a file of the
+// current schema version.
+func EncodeVersioned(key string, v interface{}) ([]byte, error) {
+	list, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return WrapList(key, list, "  ")
+}
+
+// LoadVersioned reads the list stored under key in a dex or player file.
+func LoadVersioned(file, key string, v interface{}) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	return DecodeVersioned(data, key, v)
+}
+
+// UpgradeList returns the raw list stored under key in data, upgraded to
+// the current schema version.
+func UpgradeList(data []byte, key string) (json.RawMessage, error) {
+	version, err := FileVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch version {
+	case 1:
+		return upgradeV1(data), nil
+	case SchemaVersion:
+		var file map[string]json.RawMessage
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, err
+		}
+		list, ok := file[key]
+		if !ok {
+			return nil, fmt.Errorf("schema version %d file has no %q list", version, key)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("unsupported schema version %d (this build reads versions 1-%d)", version, SchemaVersion)
+	}
+}
+
+// upgradeV1 renames the misspelt "speical_def" key of version 1 entries.
+// Renaming the raw key keeps every other field, and the field order,
+// untouched.
+func upgradeV1(list []byte) []byte {
+	return bytes.ReplaceAll(list, []byte(`"speical_def"`), []byte(`"special_def"`))
+}
+
+// WrapList builds a file of the current schema version holding the raw list
+// under key, indented by indent.
+func WrapList(key string, list json.RawMessage, indent string) ([]byte, error) {
+	var compact bytes.Buffer
+	fmt.Fprintf(&compact, `{"schema_version":%d,%q:`, SchemaVersion, key)
+	if err := json.Compact(&compact, list); err != nil {
+		return nil, err
+	}
+	compact.WriteString("}")
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,147 @@
+package dex
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Stats is a set of the six stats, used for base stats, individual values
+// (IVs), effort values (EVs) and the resulting stats of a Pokemon.
+type Stats struct {
+	HP         int `json:"hp"`
+	Attack     int `json:"attack"`
+	Defense    int `json:"defense"`
+	SpecialAtk int `json:"special_atk"`
+	SpecialDef int `json:"special_def"`
+	Speed      int `json:"speed"`
+}
+
+// MaxIV is the highest individual value of a stat.
+const MaxIV = 31
+
+// BaseStats returns the base stats of the species.
+func (p Pokemon) BaseStats() Stats {
+	return Stats{
+		HP:         p.HP,
+		Attack:     p.Attack,
+		Defense:    p.Defense,
+		SpecialAtk: p.SpecialAtk,
+		SpecialDef: p.SpecialDef,
+		Speed:      p.Speed,
+	}
+}
+
+// RandomIVs rolls the individual values of a newly met Pokemon, each
+// between 0 and 31.
+func RandomIVs(r *rand.Rand) Stats {
+	return Stats{
+		HP:         r.Intn(MaxIV + 1),
+		Attack:     r.Intn(MaxIV + 1),
+		Defense:    r.Intn(MaxIV + 1),
+		SpecialAtk: r.Intn(MaxIV + 1),
+		SpecialDef: r.Intn(MaxIV + 1),
+		Speed:      r.Intn(MaxIV + 1),
+	}
+}
+
+// Nature raises one stat by 10% and lowers another by 10%. The five
+// natures raising and lowering the same stat are neutral.
+type Nature struct {
+	Name    string
+	Raised  string
+	Lowered string
+}
+
+// natures maps each nature to the stats it raises and lowers, named as in
+// the JSON files: "attack", "defense", "special_atk", "special_def" and
+// "speed".
+var natures = map[string]Nature{
+	"Hardy":   {"Hardy", "attack", "attack"},
+	"Lonely":  {"Lonely", "attack", "defense"},
+	"Brave":   {"Brave", "attack", "speed"},
+	"Adamant": {"Adamant", "attack", "special_atk"},
+	"Naughty": {"Naughty", "attack", "special_def"},
+	"Bold":    {"Bold", "defense", "attack"},
+	"Docile":  {"Docile", "defense", "defense"},
+	"Relaxed": {"Relaxed", "defense", "speed"},
+	"Impish":  {"Impish", "defense", "special_atk"},
+	"Lax":     {"Lax", "defense", "special_def"},
+	"Timid":   {"Timid", "speed", "attack"},
+	"Hasty":   {"Hasty", "speed", "defense"},
+	"Serious": {"Serious", "speed", "speed"},
+	"Jolly":   {"Jolly", "speed", "special_atk"},
+	"Naive":   {"Naive", "speed", "special_def"},
+	"Modest":  {"Modest", "special_atk", "attack"},
+	"Mild":    {"Mild", "special_atk", "defense"},
+	"Quiet":   {"Quiet", "special_atk", "speed"},
+	"Bashful": {"Bashful", "special_atk", "special_atk"},
+	"Rash":    {"Rash", "special_atk", "special_def"},
+	"Calm":    {"Calm", "special_def", "attack"},
+	"Gentle":  {"Gentle", "special_def", "defense"},
+	"Sassy":   {"Sassy", "special_def", "speed"},
+	"Careful": {"Careful", "special_def", "special_atk"},
+	"Quirky":  {"Quirky", "special_def", "special_def"},
+}
+
+// LookupNature returns the nature with the given name, ignoring case. An
+// unknown or empty name, as on records from before natures, is neutral.
+func LookupNature(name string) Nature {
+	for _, n := range natures {
+		if strings.EqualFold(n.Name, name) {
+			return n
+		}
+	}
+	return Nature{Name: name}
+}
+
+// RandomNature picks one of the 25 natures.
+func RandomNature(r *rand.Rand) Nature {
+	names := make([]string, 0, len(natures))
+	for name := range natures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return natures[names[r.Intn(len(names))]]
+}
+
+// modifier returns the nature's multiplier for stat, in tenths.
+func (n Nature) modifier(stat string) int {
+	switch {
+	case n.Raised == n.Lowered:
+		return 10
+	case stat == n.Raised:
+		return 11
+	case stat == n.Lowered:
+		return 9
+	}
+	return 10
+}
+
+// CalcStats returns the stats of a Pokemon at level, using the formula of
+// generation III onwards:
+//
+//	HP    = (2*Base + IV + EV/4) * Level/100 + Level + 10
+//	Other = ((2*Base + IV + EV/4) * Level/100 + 5) * Nature
+//
+// rounding down at every step. Levels below 1 count as 1.
+func CalcStats(base, ivs, evs Stats, level int, nature Nature) Stats {
+	if level < 1 {
+		level = 1
+	}
+	core := func(base, iv, ev int) int {
+		return (2*base + iv + ev/4) * level / 100
+	}
+	other := func(stat string, base, iv, ev int) int {
+		return (core(base, iv, ev) + 5) * nature.modifier(stat) / 10
+	}
+
+	return Stats{
+		HP:         core(base.HP, ivs.HP, evs.HP) + level + 10,
+		Attack:     other("attack", base.Attack, ivs.Attack, evs.Attack),
+		Defense:    other("defense", base.Defense, ivs.Defense, evs.Defense),
+		SpecialAtk: other("special_atk", base.SpecialAtk, ivs.SpecialAtk, evs.SpecialAtk),
+		SpecialDef: other("special_def", base.SpecialDef, ivs.SpecialDef, evs.SpecialDef),
+		Speed:      other("speed", base.Speed, ivs.Speed, evs.Speed),
+	}
+}
@@ -0,0 +1,97 @@
+package dex
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCalcStats(t *testing.T) {
+	tests := []struct {
+		name   string
+		base   Stats
+		ivs    Stats
+		evs    Stats
+		level  int
+		nature string
+		want   Stats
+	}{
+		{
+			// The worked example of the stat formula on Bulbapedia
+			name:   "Garchomp",
+			base:   Stats{HP: 108, Attack: 130, Defense: 95, SpecialAtk: 80, SpecialDef: 85, Speed: 102},
+			ivs:    Stats{HP: 24, Attack: 12, Defense: 30, SpecialAtk: 16, SpecialDef: 23, Speed: 5},
+			evs:    Stats{HP: 74, Attack: 190, Defense: 91, SpecialAtk: 48, SpecialDef: 84, Speed: 23},
+			level:  78,
+			nature: "Adamant",
+			want:   Stats{HP: 289, Attack: 278, Defense: 193, SpecialAtk: 135, SpecialDef: 171, Speed: 171},
+		},
+		{
+			name:  "no IVs or EVs",
+			base:  Stats{HP: 100, Attack: 100, Defense: 100, SpecialAtk: 100, SpecialDef: 100, Speed: 100},
+			level: 100,
+			want:  Stats{HP: 310, Attack: 205, Defense: 205, SpecialAtk: 205, SpecialDef: 205, Speed: 205},
+		},
+		{
+			name:   "maximum IVs and EVs",
+			base:   Stats{HP: 100, Attack: 100, Defense: 100, SpecialAtk: 100, SpecialDef: 100, Speed: 100},
+			ivs:    Stats{HP: 31, Attack: 31, Defense: 31, SpecialAtk: 31, SpecialDef: 31, Speed: 31},
+			evs:    Stats{HP: 252, Attack: 252, Defense: 252, SpecialAtk: 252, SpecialDef: 252, Speed: 252},
+			level:  100,
+			nature: "modest",
+			want:   Stats{HP: 404, Attack: 269, Defense: 299, SpecialAtk: 328, SpecialDef: 299, Speed: 299},
+		},
+		{
+			name:   "neutral nature",
+			base:   Stats{HP: 35, Attack: 55, Defense: 40, SpecialAtk: 50, SpecialDef: 50, Speed: 90},
+			level:  5,
+			nature: "Hardy",
+			want:   Stats{HP: 18, Attack: 10, Defense: 9, SpecialAtk: 10, SpecialDef: 10, Speed: 14},
+		},
+		{
+			name:  "level 0 counts as 1",
+			base:  Stats{HP: 35, Attack: 55, Defense: 40, SpecialAtk: 50, SpecialDef: 50, Speed: 90},
+			level: 0,
+			want:  Stats{HP: 11, Attack: 6, Defense: 5, SpecialAtk: 6, SpecialDef: 6, Speed: 6},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := CalcStats(tt.base, tt.ivs, tt.evs, tt.level, LookupNature(tt.nature)); got != tt.want {
+			t.Errorf("%s: CalcStats = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLookupNature(t *testing.T) {
+	tests := []struct {
+		name    string
+		raised  string
+		lowered string
+	}{
+		{"Adamant", "attack", "special_atk"},
+		{"timid", "speed", "attack"},
+		{"CALM", "special_def", "attack"},
+		{"Serious", "speed", "speed"},
+		{"", "", ""},
+		{"Unknown", "", ""},
+	}
+
+	for _, tt := range tests {
+		n := LookupNature(tt.name)
+		if n.Raised != tt.raised || n.Lowered != tt.lowered {
+			t.Errorf("LookupNature(%q) = %+v, want raised %q and lowered %q", tt.name, n, tt.raised, tt.lowered)
+		}
+	}
+}
+
+func TestRandomIVs(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		ivs := RandomIVs(r)
+		for _, iv := range []int{ivs.HP, ivs.Attack, ivs.Defense, ivs.SpecialAtk, ivs.SpecialDef, ivs.Speed} {
+			if iv < 0 || iv > MaxIV {
+				t.Fatalf("RandomIVs = %+v, want every IV in 0-%d", ivs, MaxIV)
+			}
+		}
+	}
+}